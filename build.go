@@ -0,0 +1,124 @@
+package gotree
+
+import (
+	"context"
+	"sort"
+)
+
+// Graph is the tree of deps kept by the options, as rendered by Tree
+// and JSONTree, with the report at its end. Nodes and Edges flatten the
+// tree, every dep and import appears once, sorted by name.
+type Graph struct {
+	Root    *Node
+	Summary Summary
+	Nodes   []GraphNode
+	Edges   []Edge
+}
+
+// GraphNode is a dep of the graph, Type and Level are those of its
+// shallowest node in the tree
+type GraphNode struct {
+	Type    Type
+	Name    string
+	Matched bool
+	Level   int
+	Module  *Module
+}
+
+// Edge is an import between deps of the graph
+type Edge struct {
+	From, To string
+}
+
+// Build the graph of d, applying the same options as Tree
+func Build(d Dep, options ...Option) (*Graph, error) {
+	return BuildContext(context.Background(), d, options...)
+}
+
+// Build the graph of d, the traversal is aborted when ctx is done
+func BuildContext(ctx context.Context, d Dep, options ...Option) (*Graph, error) {
+	if d == nil {
+		return nil, nil
+	}
+	opts, err := buildOpts(options...)
+	if err != nil {
+		return nil, err
+	}
+	return buildGraph(ctx, d, opts)
+}
+
+func buildGraph(ctx context.Context, d Dep, opts options) (*Graph, error) {
+	root, r, err := visit(ctx, d, opts)
+	if err != nil {
+		return nil, err
+	}
+	nodes, edges := flatten(root)
+	return &Graph{Root: root, Summary: r, Nodes: nodes, Edges: edges}, nil
+}
+
+// distinct deps and imports of the tree from root, sorted by name
+func flatten(root *Node) ([]GraphNode, []Edge) {
+	nodes := make(map[string]*GraphNode)
+	edges := make(map[Edge]bool)
+	Walk(root, func(nd *Node, path []*Node) bool {
+		n, ok := nodes[nd.Name]
+		if !ok {
+			n = &GraphNode{Type: nd.Type, Name: nd.Name, Matched: nd.Matched, Level: nd.Level, Module: nd.DepModule}
+			nodes[nd.Name] = n
+		} else if nd.Level < n.Level {
+			n.Type, n.Level = nd.Type, nd.Level
+		}
+		if len(path) > 0 {
+			edges[Edge{path[len(path)-1].Name, nd.Name}] = true
+		}
+		return true
+	}, nil)
+	sortedNodes := make([]GraphNode, 0, len(nodes))
+	for _, n := range nodes {
+		sortedNodes = append(sortedNodes, *n)
+	}
+	sort.Slice(sortedNodes, func(i, j int) bool {
+		return sortedNodes[i].Name < sortedNodes[j].Name
+	})
+	sortedEdges := make([]Edge, 0, len(edges))
+	for e := range edges {
+		sortedEdges = append(sortedEdges, e)
+	}
+	sort.Slice(sortedEdges, func(i, j int) bool {
+		if sortedEdges[i].From != sortedEdges[j].From {
+			return sortedEdges[i].From < sortedEdges[j].From
+		}
+		return sortedEdges[i].To < sortedEdges[j].To
+	})
+	return sortedNodes, sortedEdges
+}
+
+// Walk the graph in dfs order, see the package function Walk
+func (g *Graph) Walk(pre func(n *Node, path []*Node) bool, post func(n *Node, path []*Node)) {
+	Walk(g.Root, pre, post)
+}
+
+// Walk the nodes from n in dfs order. pre is called before the deps of a
+// node and post after them, path holds the ancestors of the node from n.
+// If pre returns false, the deps of the node are skipped and post is not
+// called for it. Either of pre and post may be nil.
+func Walk(n *Node, pre func(n *Node, path []*Node) bool, post func(n *Node, path []*Node)) {
+	var path []*Node
+	var dfs func(n *Node)
+	dfs = func(n *Node) {
+		if pre != nil && !pre(n, path) {
+			return
+		}
+		path = append(path, n)
+		for _, d := range n.Deps {
+			dfs(d)
+		}
+		path = path[:len(path)-1]
+		if post != nil {
+			post(n, path)
+		}
+	}
+	if n != nil {
+		dfs(n)
+	}
+}
@@ -0,0 +1,103 @@
+package gotree_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild_Empty(t *testing.T) {
+	g, err := gotree.Build(nil)
+	require.NoError(t, err)
+	require.Nil(t, g)
+	// walking nothing
+	gotree.Walk(nil, func(n *gotree.Node, path []*gotree.Node) bool {
+		t.Fatal("unexpected node")
+		return true
+	}, nil)
+}
+
+func TestBuild(t *testing.T) {
+	g, err := gotree.Build(getCompleteDep(), gotree.WithMaxLevel(3))
+	require.NoError(t, err)
+	require.Equal(t, "a", g.Root.Name)
+	require.Equal(t, gotree.Root, g.Root.Type)
+	require.Len(t, g.Root.Deps, 2)
+	require.Equal(t, 8, g.Summary.Deps)
+	require.Equal(t, 2, g.Summary.Direct)
+	//
+	var pre, post []string
+	g.Walk(func(n *gotree.Node, path []*gotree.Node) bool {
+		require.Equal(t, len(path), n.Level)
+		names := make([]string, 0, len(path)+1)
+		for _, p := range path {
+			names = append(names, p.Name)
+		}
+		pre = append(pre, strings.Join(append(names, n.Name), "/"))
+		return true
+	}, func(n *gotree.Node, path []*gotree.Node) {
+		post = append(post, n.Name)
+	})
+	require.Equal(t, []string{"a", "a/b", "a/b/c", "a/b/c/d", "a/b/c/e", "a/f", "a/f/g", "a/f/h", "a/f/h/i"}, pre)
+	require.Equal(t, []string{"d", "e", "c", "b", "g", "i", "h", "f", "a"}, post)
+}
+
+func TestBuild_Nodes(t *testing.T) {
+	g, err := gotree.Build(getDiamondDep())
+	require.NoError(t, err)
+	require.Equal(t, []gotree.GraphNode{
+		{Type: gotree.Root, Name: "a", Matched: true, Level: 0},
+		{Type: gotree.Direct, Name: "b", Matched: true, Level: 1},
+		{Type: gotree.Direct, Name: "c", Matched: true, Level: 1},
+		{Type: gotree.Indirect, Name: "d", Matched: true, Level: 2},
+		{Type: gotree.Indirect, Name: "e", Matched: true, Level: 2},
+	}, g.Nodes)
+	require.Equal(t, []gotree.Edge{
+		{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"},
+		{From: "c", To: "d"}, {From: "c", To: "e"}, {From: "d", To: "e"},
+	}, g.Edges)
+	// modules are known without WithVersions
+	m := &gotree.Module{Path: "m", Version: "v1.0.0"}
+	g, err = gotree.Build(&mockModuleDep{mockDep{name: "m"}, m})
+	require.NoError(t, err)
+	require.Nil(t, g.Root.Module)
+	require.Equal(t, m, g.Root.DepModule)
+	require.Equal(t, m, g.Nodes[0].Module)
+}
+
+func TestBuild_Filter(t *testing.T) {
+	g, err := gotree.Build(getCompleteDep(), gotree.WithFilter(fixedFilter{"i"}))
+	require.NoError(t, err)
+	var matched []string
+	gotree.Walk(g.Root, func(n *gotree.Node, path []*gotree.Node) bool {
+		if n.Matched {
+			matched = append(matched, n.Name)
+		}
+		return true
+	}, nil)
+	require.Equal(t, []string{"i"}, matched)
+	require.Equal(t, 3, g.Summary.Deps)
+}
+
+func TestWalk_Skip(t *testing.T) {
+	g, err := gotree.Build(getCompleteDep())
+	require.NoError(t, err)
+	var post []string
+	g.Walk(func(n *gotree.Node, path []*gotree.Node) bool {
+		return n.Name != "b"
+	}, func(n *gotree.Node, path []*gotree.Node) {
+		post = append(post, n.Name)
+	})
+	require.Equal(t, []string{"g", "i", "h", "f", "a"}, post)
+}
+
+func TestBuildContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g, err := gotree.BuildContext(ctx, getCompleteDep())
+	require.Equal(t, context.Canceled, err)
+	require.Nil(t, g)
+}
@@ -0,0 +1,178 @@
+package gotree
+
+// Change of a dep between two graphs
+type Change string
+
+const (
+	// only in the new graph
+	Added Change = "added"
+	// only in the old graph
+	Removed Change = "removed"
+	// in both graphs, but moved between direct and indirect deps
+	// or with another module version
+	Changed Change = "changed"
+)
+
+// marker of a change in a tree
+func (c Change) marker() string {
+	switch c {
+	case Added:
+		return "+ "
+	case Removed:
+		return "- "
+	case Changed:
+		return "~ "
+	}
+	return ""
+}
+
+// DiffDep is a dep of the graph returned by Diff, Change is empty if the dep
+// did not change, OldModule is the module of the dep in the old graph if it
+// has another version there
+type DiffDep interface {
+	Dep
+	Change() Change
+	OldModule() *Module
+}
+
+// Compare two graphs of the same root, the returned DiffDep merges them,
+// with every dep marked with its change. A nil graph is empty.
+func Diff(before, after Dep) Dep {
+	if before == nil && after == nil {
+		return nil
+	}
+	d := &diff{before: emptyGraph(), after: emptyGraph()}
+	name := ""
+	if before != nil {
+		d.before = newGraph(before)
+		name = before.Name()
+	}
+	if after != nil {
+		d.after = newGraph(after)
+		name = after.Name()
+	}
+	return &diffDep{d: d, name: name, root: true}
+}
+
+func emptyGraph() *graph {
+	return &graph{deps: make(map[string]Dep), edges: make(map[string][]string)}
+}
+
+type diff struct {
+	before, after *graph
+}
+
+// deps of name in g, with the root of g as the root of the diff
+func (d *diff) edges(g *graph, name string, root bool) []string {
+	if root {
+		return g.edges[g.root]
+	}
+	return g.edges[name]
+}
+
+// whether name is imported by the root of g
+func (d *diff) direct(g *graph, name string) bool {
+	for _, n := range g.edges[g.root] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type diffDep struct {
+	d    *diff
+	name string
+	// the root of the diff, whose name may differ between the graphs
+	root bool
+}
+
+func (d *diffDep) Name() string {
+	return d.name
+}
+
+func (d *diffDep) Deps() []Dep {
+	var deps []Dep
+	seen := make(map[string]bool)
+	for _, g := range []*graph{d.d.before, d.d.after} {
+		for _, name := range d.d.edges(g, d.name, d.root) {
+			if !seen[name] {
+				seen[name] = true
+				deps = append(deps, &diffDep{d: d.d, name: name})
+			}
+		}
+	}
+	return deps
+}
+
+func (d *diffDep) Change() Change {
+	if d.root {
+		return ""
+	}
+	before, inBefore := d.d.before.deps[d.name]
+	after, inAfter := d.d.after.deps[d.name]
+	switch {
+	case !inBefore:
+		return Added
+	case !inAfter:
+		return Removed
+	case d.d.direct(d.d.before, d.name) != d.d.direct(d.d.after, d.name):
+		return Changed
+	case !sameModule(moduleOf(before), moduleOf(after)):
+		return Changed
+	}
+	return ""
+}
+
+func (d *diffDep) OldModule() *Module {
+	before, inBefore := d.d.before.deps[d.name]
+	after, inAfter := d.d.after.deps[d.name]
+	if !inBefore || !inAfter || sameModule(moduleOf(before), moduleOf(after)) {
+		return nil
+	}
+	return moduleOf(before)
+}
+
+// the dep in the new graph, or in the old one if it was removed
+func (d *diffDep) dep() Dep {
+	if d.root {
+		if dep, ok := d.d.after.deps[d.d.after.root]; ok {
+			return dep
+		}
+		return d.d.before.deps[d.d.before.root]
+	}
+	if dep, ok := d.d.after.deps[d.name]; ok {
+		return dep
+	}
+	return d.d.before.deps[d.name]
+}
+
+func (d *diffDep) Module() *Module {
+	return moduleOf(d.dep())
+}
+
+func (d *diffDep) Errors() []error {
+	if ed, ok := d.dep().(ErrorDep); ok {
+		return ed.Errors()
+	}
+	return nil
+}
+
+func (d *diffDep) Std() bool {
+	return isStd(d.dep())
+}
+
+func moduleOf(d Dep) *Module {
+	if md, ok := d.(ModuleDep); ok {
+		return md.Module()
+	}
+	return nil
+}
+
+// whether two modules have the same path, version and replacement
+func sameModule(a, b *Module) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Path == b.Path && a.Version == b.Version && sameModule(a.Replace, b.Replace)
+}
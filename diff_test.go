@@ -0,0 +1,96 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldD := &mockDep{name: "d"}
+	old := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{oldD}},
+		&mockDep{name: "c"},
+	}}
+	newD := &mockDep{name: "d"}
+	new := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{newD}},
+		newD,
+		&mockDep{name: "e"},
+	}}
+	tree, err := gotree.Tree(gotree.Diff(old, new))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+│   └── ~ d
+├── - c
+├── ~ d
+└── + e
+5 deps, 4 direct, 1 indirect (4 unique, 4 direct, 0 indirect), 1 added, 1 removed, 1 changed`, "\n"+tree)
+	// nothing changed
+	tree, err = gotree.Tree(gotree.Diff(old, old))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+│   └── d
+└── c
+3 deps, 2 direct, 1 indirect (3 unique, 2 direct, 1 indirect)`, "\n"+tree)
+}
+
+func TestDiff_Versions(t *testing.T) {
+	oldX := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	newX := &gotree.Module{Path: "x", Version: "v1.1.0"}
+	old := &mockDep{name: "a", deps: []gotree.Dep{&mockModuleDep{mockDep{name: "x/b"}, oldX}}}
+	new := &mockDep{name: "a", deps: []gotree.Dep{&mockModuleDep{mockDep{name: "x/b"}, newX}}}
+	tree, err := gotree.Tree(gotree.Diff(old, new), gotree.WithVersions(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+└── ~ x/b@v1.1.0 (was v1.0.0)
+1 deps, 1 direct, 0 indirect (1 unique, 1 direct, 0 indirect), 1 modules, 1 changed`, "\n"+tree)
+	// json keeps the change
+	json, err := gotree.JSONTree(gotree.Diff(old, new), gotree.WithVersions(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "a",
+  "Matched": true,
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "x/b",
+    "Matched": true,
+    "Module": {
+     "Path": "x",
+     "Version": "v1.1.0"
+    },
+    "Change": "changed",
+    "OldModule": {
+     "Path": "x",
+     "Version": "v1.0.0"
+    }
+   }
+  ]
+ }
+]`, "\n"+json)
+}
+
+func TestDiff_Nil(t *testing.T) {
+	require.Nil(t, gotree.Diff(nil, nil))
+	tree, err := gotree.Tree(gotree.Diff(nil, getDiamondDep()), gotree.WithDedup(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── + b
+│   └── + d
+│       └── + e
+└── + c
+    ├── + d (*)
+    └── + e
+`, "\n"+tree)
+}
@@ -0,0 +1,67 @@
+package gotree
+
+// immediate dominators of the graph of edges from root, by name, with the
+// algorithm of Cooper, Harvey and Kennedy. The root and the deps which are
+// not reachable from it have no immediate dominator.
+func dominators(root string, edges []Edge) map[string]string {
+	succ := make(map[string][]string)
+	pred := make(map[string][]string)
+	for _, e := range edges {
+		succ[e.From] = append(succ[e.From], e.To)
+		pred[e.To] = append(pred[e.To], e.From)
+	}
+	// reverse postorder
+	var post []string
+	seen := map[string]bool{root: true}
+	var dfs func(name string)
+	dfs = func(name string) {
+		for _, s := range succ[name] {
+			if !seen[s] {
+				seen[s] = true
+				dfs(s)
+			}
+		}
+		post = append(post, name)
+	}
+	dfs(root)
+	order := make(map[string]int, len(post))
+	rpo := make([]string, len(post))
+	for i, name := range post {
+		order[name] = len(post) - 1 - i
+		rpo[len(post)-1-i] = name
+	}
+	idom := map[string]string{root: root}
+	intersect := func(a, b string) string {
+		for a != b {
+			for order[a] > order[b] {
+				a = idom[a]
+			}
+			for order[b] > order[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, name := range rpo[1:] {
+			newIdom := ""
+			for _, p := range pred[name] {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if newIdom == "" {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if idom[name] != newIdom {
+				idom[name] = newIdom
+				changed = true
+			}
+		}
+	}
+	delete(idom, root)
+	return idom
+}
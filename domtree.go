@@ -0,0 +1,67 @@
+package gotree
+
+import "sort"
+
+// Get the dominator tree of d, where the deps of a dep are the deps only
+// reachable through it, so every dep appears once, under the dep through
+// which it is unavoidably reached. The returned deps keep the module and
+// errors of the deps of d.
+func Dominators(d Dep) Dep {
+	if d == nil {
+		return nil
+	}
+	g := newGraph(d)
+	var edges []Edge
+	for _, name := range g.names() {
+		for _, to := range g.edges[name] {
+			edges = append(edges, Edge{name, to})
+		}
+	}
+	t := &domTree{g: g, children: make(map[string][]string)}
+	for name, idom := range dominators(g.root, edges) {
+		t.children[idom] = append(t.children[idom], name)
+	}
+	for _, children := range t.children {
+		sort.Strings(children)
+	}
+	return &domDep{t: t, name: g.root}
+}
+
+type domTree struct {
+	g *graph
+	// deps immediately dominated by a dep
+	children map[string][]string
+}
+
+type domDep struct {
+	t    *domTree
+	name string
+}
+
+func (d *domDep) Name() string {
+	return d.name
+}
+
+func (d *domDep) Deps() []Dep {
+	children := d.t.children[d.name]
+	deps := make([]Dep, 0, len(children))
+	for _, name := range children {
+		deps = append(deps, &domDep{t: d.t, name: name})
+	}
+	return deps
+}
+
+func (d *domDep) Module() *Module {
+	return moduleOf(d.t.g.deps[d.name])
+}
+
+func (d *domDep) Errors() []error {
+	if ed, ok := d.t.g.deps[d.name].(ErrorDep); ok {
+		return ed.Errors()
+	}
+	return nil
+}
+
+func (d *domDep) Std() bool {
+	return isStd(d.t.g.deps[d.name])
+}
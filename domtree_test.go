@@ -0,0 +1,53 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDominators(t *testing.T) {
+	require.Nil(t, gotree.Dominators(nil))
+	// d and e are reachable through both b and c
+	tree, err := gotree.Tree(gotree.Dominators(getDiamondDep()))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+├── c
+├── d
+└── e
+4 deps, 4 direct, 0 indirect (4 unique, 4 direct, 0 indirect)`, "\n"+tree)
+	// a tree is its own dominator tree
+	expected, err := gotree.Tree(getCompleteDep())
+	require.NoError(t, err)
+	tree, err = gotree.Tree(gotree.Dominators(getCompleteDep()))
+	require.NoError(t, err)
+	require.Equal(t, expected, tree)
+	// cycles are broken
+	tree, err = gotree.Tree(gotree.Dominators(getCycleDep()), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+└── b
+    └── c
+`, "\n"+tree)
+}
+
+func TestDominators_Modules(t *testing.T) {
+	x := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	shared := &mockModuleDep{mockDep{name: "x/c"}, x}
+	root := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "x/a", deps: []gotree.Dep{shared}}, x},
+		&mockModuleDep{mockDep{name: "x/b", deps: []gotree.Dep{shared}}, x},
+	}}
+	tree, err := gotree.Tree(gotree.Dominators(root), gotree.WithVersions(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── x/a@v1.0.0
+├── x/b@v1.0.0
+└── x/c@v1.0.0
+`, "\n"+tree)
+}
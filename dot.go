@@ -0,0 +1,95 @@
+package gotree
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// name of a graph node, with its module version if asked for
+func (n GraphNode) label(opts options) string {
+	if opts.versions && n.Module != nil {
+		return n.Name + n.Module.suffix()
+	}
+	return n.Name
+}
+
+// whether matched deps should be highlighted
+func highlight(opts options) bool {
+	_, nop := opts.filter.(nopFilter)
+	return !nop
+}
+
+var dotStyles = map[Type]string{
+	Root:     `shape=box, style="bold,filled", fillcolor=lightblue`,
+	Direct:   `style=filled, fillcolor=lightgrey`,
+	Indirect: `style=solid`,
+}
+
+// Get dep graph(graphviz dot), every dep appears once
+func DOT(d Dep, options ...Option) (string, error) {
+	if d == nil {
+		return "", nil
+	}
+	opts, err := buildOpts(options...)
+	if err != nil {
+		return "", err
+	}
+	// every dep appears once in dedup mode, the traversal can only fail
+	// on cancellation
+	opts.dedup = true
+	g, _ := buildGraph(context.Background(), d, opts)
+	nodes, edges := g.Nodes, g.Edges
+	//
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "digraph %s {\n", strconv.Quote(d.Name()))
+	sb.WriteString("\tnode [shape=ellipse];\n")
+	writeNode := func(indent string, n GraphNode) {
+		attrs := dotStyles[n.Type]
+		if label := n.label(opts); label != n.Name {
+			attrs += ", label=" + strconv.Quote(label)
+		}
+		if n.Matched && highlight(opts) {
+			attrs += ", color=red, fontcolor=red"
+		}
+		fmt.Fprintf(sb, "%s%s [%s];\n", indent, strconv.Quote(n.Name), attrs)
+	}
+	if opts.clusters {
+		var modules []string
+		clusters := make(map[string][]GraphNode)
+		for _, n := range nodes {
+			if n.Module == nil {
+				writeNode("\t", n)
+				continue
+			}
+			label := n.Module.Path
+			if n.Module.Version != "" {
+				label += "@" + n.Module.Version
+			}
+			if _, ok := clusters[label]; !ok {
+				modules = append(modules, label)
+			}
+			clusters[label] = append(clusters[label], n)
+		}
+		sort.Strings(modules)
+		for i, label := range modules {
+			fmt.Fprintf(sb, "\tsubgraph cluster_%d {\n", i)
+			fmt.Fprintf(sb, "\t\tlabel=%s;\n", strconv.Quote(label))
+			for _, n := range clusters[label] {
+				writeNode("\t\t", n)
+			}
+			sb.WriteString("\t}\n")
+		}
+	} else {
+		for _, n := range nodes {
+			writeNode("\t", n)
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(sb, "\t%s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
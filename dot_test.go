@@ -0,0 +1,51 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDOT(t *testing.T) {
+	dot, err := gotree.DOT(nil)
+	require.NoError(t, err)
+	require.Equal(t, "", dot)
+
+	dot, err = gotree.DOT(getDiamondDep(), gotree.WithFilter(fixedFilter{"d"}))
+	require.NoError(t, err)
+	require.Equal(t, `digraph "a" {
+	node [shape=ellipse];
+	"a" [shape=box, style="bold,filled", fillcolor=lightblue];
+	"b" [style=filled, fillcolor=lightgrey];
+	"c" [style=filled, fillcolor=lightgrey];
+	"d" [style=solid, color=red, fontcolor=red];
+	"a" -> "b";
+	"a" -> "c";
+	"b" -> "d";
+	"c" -> "d";
+}
+`, dot)
+}
+
+func TestDOT_Clusters(t *testing.T) {
+	x := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	root := &mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "x/a"}, x},
+		&mockModuleDep{mockDep{name: "x/b"}, x},
+	}}
+	dot, err := gotree.DOT(root, gotree.WithModuleClusters(true))
+	require.NoError(t, err)
+	require.Equal(t, `digraph "m" {
+	node [shape=ellipse];
+	"m" [shape=box, style="bold,filled", fillcolor=lightblue];
+	subgraph cluster_0 {
+		label="x@v1.0.0";
+		"x/a" [style=filled, fillcolor=lightgrey];
+		"x/b" [style=filled, fillcolor=lightgrey];
+	}
+	"m" -> "x/a";
+	"m" -> "x/b";
+}
+`, dot)
+}
@@ -0,0 +1,4 @@
+package gotree
+
+// environment of the go tool for a loader, exported for tests
+var LoaderEnv = Loader.env
@@ -0,0 +1,100 @@
+package gotree
+
+import (
+	"context"
+	"sync"
+)
+
+// fetcher memoizes Deps per dep name, and prefetches the deps of the deps
+// of a dep with a bounded number of goroutines. No Deps call starts once
+// ctx is done. One which already started can not be interrupted: waiting
+// for it in deps stops on cancellation, but wait still awaits it.
+type fetcher struct {
+	ctx     context.Context
+	sem     chan struct{}
+	mu      sync.Mutex
+	entries map[string]*fetchEntry
+	// prefetching goroutines
+	wg sync.WaitGroup
+}
+
+type fetchEntry struct {
+	done chan struct{}
+	deps []Dep
+}
+
+func newFetcher(ctx context.Context, workers int) *fetcher {
+	return &fetcher{
+		ctx:     ctx,
+		sem:     make(chan struct{}, workers),
+		entries: make(map[string]*fetchEntry),
+	}
+}
+
+// get the deps of d, nil if ctx is done before they are fetched
+func (f *fetcher) deps(d Dep) []Dep {
+	name := d.Name()
+	f.mu.Lock()
+	e, ok := f.entries[name]
+	if !ok && f.ctx.Err() != nil {
+		f.mu.Unlock()
+		return nil
+	}
+	if !ok {
+		e = &fetchEntry{done: make(chan struct{})}
+		f.entries[name] = e
+	}
+	f.mu.Unlock()
+	if !ok {
+		e.deps = d.Deps()
+		close(e.done)
+	}
+	select {
+	case <-e.done:
+	case <-f.ctx.Done():
+		return nil
+	}
+	for _, _dep := range e.deps {
+		if f.ctx.Err() != nil {
+			break
+		}
+		f.prefetch(_dep)
+	}
+	return e.deps
+}
+
+// fetch the deps of d in background if a worker is free
+func (f *fetcher) prefetch(d Dep) {
+	if f.ctx.Err() != nil {
+		return
+	}
+	select {
+	case f.sem <- struct{}{}:
+	default:
+		return
+	}
+	name := d.Name()
+	f.mu.Lock()
+	if _, ok := f.entries[name]; ok || f.ctx.Err() != nil {
+		f.mu.Unlock()
+		<-f.sem
+		return
+	}
+	e := &fetchEntry{done: make(chan struct{})}
+	f.entries[name] = e
+	f.mu.Unlock()
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer func() { <-f.sem }()
+		e.deps = d.Deps()
+		close(e.done)
+	}()
+}
+
+// wait for the Deps calls started in background, so none is running once
+// the traversal returns. After cancellation no new call starts, but those
+// in flight are still awaited.
+func (f *fetcher) wait() {
+	f.wg.Wait()
+}
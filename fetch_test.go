@@ -0,0 +1,94 @@
+package gotree_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDep is a complete binary tree of the given depth, which deps
+// take delay to be fetched
+type slowDep struct {
+	name  string
+	depth int
+	delay time.Duration
+	calls *int64
+}
+
+func (d slowDep) Name() string {
+	return d.name
+}
+
+func (d slowDep) Deps() []gotree.Dep {
+	atomic.AddInt64(d.calls, 1)
+	time.Sleep(d.delay)
+	if d.depth == 0 {
+		return nil
+	}
+	var deps []gotree.Dep
+	for i := 0; i < 2; i++ {
+		deps = append(deps, slowDep{
+			// deps are shared between levels, as in a diamond
+			name:  fmt.Sprintf("%d-%d", d.depth-1, i),
+			depth: d.depth - 1,
+			delay: d.delay,
+			calls: d.calls,
+		})
+	}
+	return deps
+}
+
+func TestTreeContext(t *testing.T) {
+	var calls int64
+	root := slowDep{name: "root", depth: 4, calls: &calls}
+	tree, err := gotree.Tree(root)
+	require.NoError(t, err)
+	serialCalls := atomic.LoadInt64(&calls)
+
+	atomic.StoreInt64(&calls, 0)
+	concurrent, err := gotree.TreeContext(context.Background(), root, gotree.WithConcurrency(4))
+	require.NoError(t, err)
+	require.Equal(t, tree, concurrent)
+	// deps are memoized by name
+	require.True(t, atomic.LoadInt64(&calls) < serialCalls)
+	require.EqualValues(t, 9, atomic.LoadInt64(&calls))
+
+	json, err := gotree.JSONTree(root)
+	require.NoError(t, err)
+	concurrent, err = gotree.JSONTreeContext(context.Background(), root, gotree.WithConcurrency(4))
+	require.NoError(t, err)
+	require.Equal(t, json, concurrent)
+}
+
+func TestTreeContext_Cancel(t *testing.T) {
+	var calls int64
+	root := slowDep{name: "root", depth: 20, delay: time.Millisecond, calls: &calls}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := gotree.TreeContext(ctx, root, gotree.WithConcurrency(2))
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, time.Since(start) < time.Second)
+	// no Deps call is left running in background
+	n := atomic.LoadInt64(&calls)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, n, atomic.LoadInt64(&calls))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = gotree.JSONTreeContext(ctx, root)
+	require.Equal(t, context.Canceled, err)
+	// no Deps call starts once ctx is done
+	atomic.StoreInt64(&calls, 0)
+	_, err = gotree.TreeContext(ctx, root, gotree.WithConcurrency(2))
+	require.Equal(t, context.Canceled, err)
+	require.EqualValues(t, 0, atomic.LoadInt64(&calls))
+
+	_, err = gotree.Tree(root, gotree.WithConcurrency(-1))
+	require.Error(t, err)
+}
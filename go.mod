@@ -1,14 +1,22 @@
 module github.com/MaruHyl/gotree
 
-go 1.12
+go 1.22.0
 
 require (
 	github.com/fatih/color v1.7.0
+	github.com/spf13/cobra v0.0.3
+	github.com/stretchr/testify v1.3.0
+	golang.org/x/tools v0.28.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/mattn/go-colorable v0.1.1 // indirect
 	github.com/mattn/go-isatty v0.0.7 // indirect
-	github.com/spf13/cobra v0.0.3
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.3 // indirect
-	github.com/stretchr/testify v1.3.0
-	golang.org/x/tools v0.0.0-20190516015132-d1a3278ee749
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )
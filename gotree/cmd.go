@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/MaruHyl/gotree"
 	"github.com/spf13/cobra"
@@ -10,80 +14,368 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		panic(err)
+		code := exitCode(err)
+		fmt.Fprintf(os.Stderr, "gotree: %v\n", err)
+		if code == exitUsage {
+			fmt.Fprintln(os.Stderr, "Run 'gotree --help' for usage.")
+		}
+		os.Exit(code)
 	}
 }
 
 func init() {
-	cmd.Flags().IntVarP(
+	cmd.PersistentFlags().IntVarP(
 		&maxLevel, "max_level", "l", 0, "Set max level of tree")
-	cmd.Flags().BoolVar(&noReport, "noreport", false, "Turn off dep/direct/indirect count at end of tree listing")
-	cmd.Flags().StringVarP(
+	cmd.PersistentFlags().BoolVar(&noReport, "noreport", false, "Turn off dep/direct/indirect count at end of tree listing")
+	cmd.PersistentFlags().StringVarP(
 		&pattern, "pattern", "p", "", "List only those deps that match the pattern given")
-	cmd.Flags().BoolVarP(&json, "json", "j", false, "Prints out an JSON representation of the tree")
-	cmd.Flags().BoolVar(&noStd, "nostd", false, "Filter out std packages")
-	cmd.Flags().BoolVar(&noInternal, "nointernal", false, "Filter out internal packages")
+	cmd.PersistentFlags().BoolVarP(&json, "json", "j", false, "Prints out an JSON representation of the tree")
+	cmd.PersistentFlags().StringVarP(&format, "format", "f", "tree", "Output format: tree, json, dot or mermaid")
+	cmd.PersistentFlags().BoolVar(&modules, "modules", false, "Show the tree of modules instead of packages")
+	cmd.PersistentFlags().BoolVar(&versions, "versions", false, "Print the module version of packages")
+	cmd.PersistentFlags().BoolVar(&symbols, "symbols", false, "Type-check packages and print the exported symbols used from each dep")
+	cmd.PersistentFlags().BoolVar(&tests, "tests", false, "Include the deps of test files, marking those only needed by tests")
+	cmd.PersistentFlags().StringVar(&goos, "goos", "", "Load packages for the given GOOS")
+	cmd.PersistentFlags().StringVar(&goarch, "goarch", "", "Load packages for the given GOARCH")
+	cmd.PersistentFlags().StringSliceVar(&tags, "tags", nil, "Comma separated build tags")
+	cmd.PersistentFlags().BoolVar(&cgo, "cgo", false, "Enable cgo, default to CGO_ENABLED")
+	cmd.PersistentFlags().StringSliceVar(&platforms, "platforms", nil,
+		"Comma separated GOOS/GOARCH list, merge the trees of all platforms and mark platform specific deps")
+	cmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail if any package in the graph has errors")
+	cmd.PersistentFlags().BoolVar(&clusters, "clusters", false, "Group packages by module in dot output")
+	cmd.PersistentFlags().BoolVar(&noStd, "nostd", false, "Filter out std packages")
+	cmd.PersistentFlags().BoolVar(&noInternal, "nointernal", false, "Filter out internal packages")
+	cmd.PersistentFlags().BoolVar(&full, "full", false, "Expand repeated deps instead of marking them with (*)")
+	cmd.Flags().BoolVar(&dominators, "dominators", false,
+		"Print the dominator tree, every dep under the dep through which it is unavoidably reached")
+	cmd.PersistentFlags().StringVarP(&input, "input", "i", "", "Read the graph from a json output of gotree instead of loading packages, - for stdin")
 }
 
 var maxLevel int
 var noReport bool
 var pattern string
 var json bool
+var format string
+var clusters bool
+var modules bool
+var versions bool
+var tests bool
+var symbols bool
+var goos string
+var goarch string
+var tags []string
+var cgo bool
+var cgoChanged bool
+var platforms []string
+var strict bool
 var noStd bool
 var noInternal bool
+var full bool
+var input string
+var dominators bool
 
 var cmd = &cobra.Command{
-	Use:   "gotree",
+	Use:   "gotree [packages]",
 	Short: "Show deps like tree.",
-	Long:  "Show deps like tree.",
-	Args:  cobra.NoArgs,
-	Run: func(cmd *cobra.Command, args []string) {
-		opts := []gotree.Option{
-			gotree.WithMaxLevel(maxLevel),
-			gotree.WithNoReport(noReport),
-			gotree.WithNoStd(noStd),
-			gotree.WithNoInternal(noInternal),
-		}
-		if pattern != "" {
-			patternFilter, err := gotree.NewRegexpFilter(pattern)
-			if err != nil {
-				panic(fmt.Errorf("compile pattern error: %v", err))
+	Long: `Show deps like tree.
+
+Packages are given as patterns understood by the go tool, e.g. ./... or
+./cmd/api ./cmd/worker. With no pattern, the package in the current
+directory is used. When more than one package matches, their trees are
+grouped under a synthetic root named after the patterns.
+
+Exit codes:
+  1  the tree could not be printed
+  2  bad flags or arguments
+  3  packages could not be loaded, or a given package has errors
+  4  the graph violates a policy flag, e.g. --strict
+
+Errors of packages are marked in the output, the exit code is decided once
+it is printed.`,
+	Args:          cobra.ArbitraryArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cgoChanged = cmd.Flags().Changed("cgo")
+		rootErrors = make(map[string][]string)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := buildOptions()
+		if err != nil {
+			return err
+		}
+		var sizes map[string]int64
+		if sizeMode() {
+			if sizes, err = loadSizes(args); err != nil {
+				return err
 			}
-			opts = append(opts, gotree.WithFilter(gotree.NewReverseFilter(patternFilter)))
 		}
-		root, err := gotree.LoadPackages()
+		pkgRoot, err := loadPackageRoot("", args)
 		if err != nil {
-			panic(err)
+			return err
+		}
+		root := pkgRoot
+		if modules {
+			root = gotree.Modules(root)
 		}
+		tree := root
+		if dominators {
+			tree = gotree.Dominators(root)
+		}
+		if sizes != nil {
+			if modules {
+				sizes = gotree.ModuleSizes(pkgRoot, sizes)
+			}
+			opts = append(opts, gotree.WithSizes(sizes), gotree.WithSizeSort(sortSize))
+		}
+		if err := render(tree, opts); err != nil {
+			return err
+		}
+		return checkErrors(root)
+	},
+}
+
+// options shared by all commands
+func buildOptions() ([]gotree.Option, error) {
+	opts := []gotree.Option{
+		gotree.WithMaxLevel(maxLevel),
+		gotree.WithNoReport(noReport),
+		gotree.WithNoStd(noStd),
+		gotree.WithNoInternal(noInternal),
+		gotree.WithDedup(!full),
+		gotree.WithModuleClusters(clusters),
+		gotree.WithVersions(versions || modules),
+	}
+	if pattern != "" {
+		patternFilter, err := gotree.NewRegexpFilter(pattern)
+		if err != nil {
+			return nil, usageError("compile pattern error: %v", err)
+		}
+		opts = append(opts, gotree.WithFilter(gotree.NewReverseFilter(patternFilter)))
+	}
+	switch outputFormat() {
+	case "tree", "json", "dot", "mermaid":
+	default:
+		return nil, usageError("unknown format: %s", format)
+	}
+	return opts, nil
+}
+
+// output format, --json is a shorthand of --format json
+func outputFormat() string {
+	if json {
+		return "json"
+	}
+	return format
+}
+
+// print root in the output format, trees are written as they are traversed
+func render(root gotree.Dep, opts []gotree.Option) error {
+	w := &lineWriter{w: os.Stdout}
+	var err error
+	switch outputFormat() {
+	case "tree":
+		if err = gotree.WriteTree(w, root, opts...); err != nil {
+			return failure("build tree error: %v", err)
+		}
+	case "json":
+		if err = gotree.WriteJSON(w, root, opts...); err != nil {
+			return failure("build json error: %v", err)
+		}
+	case "dot":
 		var str string
-		if json {
-			str, err = gotree.JSONTree(dep{root}, opts...)
-			if err != nil {
-				panic(fmt.Errorf("build json error: %v", err))
+		if str, err = gotree.DOT(root, opts...); err != nil {
+			return failure("build dot error: %v", err)
+		}
+		io.WriteString(w, str)
+	case "mermaid":
+		var str string
+		if str, err = gotree.Mermaid(root, opts...); err != nil {
+			return failure("build mermaid error: %v", err)
+		}
+		io.WriteString(w, str)
+	}
+	if w.last != '\n' {
+		io.WriteString(w, "\n")
+	}
+	return nil
+}
+
+// lineWriter remembers the last byte written, to end the output
+// with a new line
+type lineWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		lw.last = p[len(p)-1]
+	}
+	return lw.w.Write(p)
+}
+
+// errors of the packages given on the command line, by package
+var rootErrors map[string][]string
+
+func recordRootErrors(pkgs []*packages.Package) {
+	for _, pkg := range pkgs {
+	next:
+		for _, e := range pkg.Errors {
+			// packages are loaded again for every platform
+			for _, msg := range rootErrors[pkg.ID] {
+				if msg == e.Msg {
+					continue next
+				}
+			}
+			rootErrors[pkg.ID] = append(rootErrors[pkg.ID], e.Msg)
+		}
+	}
+}
+
+// once the graph is printed, with errors marked on their deps, report
+// the errors of the given packages and fail if any, then in strict mode
+// the errors of the whole graph
+func checkErrors(root gotree.Dep) error {
+	if len(rootErrors) > 0 {
+		ids := make([]string, 0, len(rootErrors))
+		for id := range rootErrors {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			for _, msg := range rootErrors[id] {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", id, msg)
+			}
+		}
+		return loadError(fmt.Errorf("%d given packages have errors", len(ids)))
+	}
+	if !strict {
+		return nil
+	}
+	errs := gotree.DepErrors(root)
+	if len(errs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, err := range errs[name] {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		}
+	}
+	return &exitError{exitPolicy, fmt.Errorf("%d packages have errors", len(errs))}
+}
+
+// forest groups several roots under a synthetic root
+type forest struct {
+	name string
+	deps []gotree.Dep
+}
+
+func (f forest) Name() string {
+	return f.name
+}
+
+func (f forest) Deps() []gotree.Dep {
+	return f.deps
+}
+
+// load packages matched by patterns in dir, grouping them under a forest if
+// more than one package matches, by platform in platforms mode, and by
+// module in modules mode
+func loadRoot(dir string, patterns []string) (gotree.Dep, error) {
+	root, err := loadPackageRoot(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	if modules {
+		root = gotree.Modules(root)
+	}
+	return root, nil
+}
+
+// load packages like loadRoot, without grouping them by module
+func loadPackageRoot(dir string, patterns []string) (gotree.Dep, error) {
+	if input != "" {
+		if len(patterns) > 0 || len(platforms) > 0 {
+			return nil, usageError("--input can not be used with packages or --platforms")
+		}
+		return readInput(input)
+	}
+	l := newLoader(dir)
+	var root gotree.Dep
+	var err error
+	if len(platforms) == 0 {
+		root, err = load(l, patterns)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		roots := make([]gotree.Dep, 0, len(platforms))
+		for _, platform := range platforms {
+			i := strings.Index(platform, "/")
+			if i < 0 {
+				return nil, usageError("invalid platform %q, expect GOOS/GOARCH", platform)
 			}
-		} else {
-			str, err = gotree.Tree(dep{root}, opts...)
+			l.GOOS, l.GOARCH = platform[:i], platform[i+1:]
+			root, err := load(l, patterns)
 			if err != nil {
-				panic(fmt.Errorf("build tree error: %v", err))
+				return nil, loadError(fmt.Errorf("%s: %v", platform, err))
 			}
+			roots = append(roots, root)
 		}
-		fmt.Println(str)
-	},
+		root = gotree.Union(platforms, roots)
+	}
+	return root, nil
 }
 
-type dep struct {
-	pkg *packages.Package
+// loader of packages in dir, configured by the build context flags
+func newLoader(dir string) gotree.Loader {
+	l := gotree.Loader{
+		Tests:  tests,
+		GOOS:   goos,
+		GOARCH: goarch,
+		Tags:   tags,
+		Dir:    dir,
+		Types:  symbols,
+	}
+	if cgoChanged {
+		l.Cgo = &cgo
+	}
+	return l
 }
 
-func (d dep) Name() string {
-	return d.pkg.PkgPath
+// read a graph saved as json from a file, or stdin for -
+func readInput(name string) (gotree.Dep, error) {
+	r := os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, loadError(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	root, _, err := gotree.ReadJSON(r)
+	if err != nil {
+		return nil, loadError(fmt.Errorf("%s: %v", name, err))
+	}
+	return root, nil
 }
 
-func (d dep) Deps() []gotree.Dep {
-	imports := d.pkg.Imports
-	deps := make([]gotree.Dep, 0, len(imports))
-	for _, i := range imports {
-		deps = append(deps, dep{i})
+func load(l gotree.Loader, patterns []string) (gotree.Dep, error) {
+	pkgs, err := l.Load(patterns...)
+	if err != nil {
+		return nil, loadError(err)
+	}
+	recordRootErrors(pkgs)
+	roots := gotree.NewPackageDeps(pkgs)
+	if len(roots) == 1 {
+		return roots[0], nil
 	}
-	return deps
+	return forest{name: strings.Join(patterns, " "), deps: roots}, nil
 }
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRoot_Forest(t *testing.T) {
+	root, err := loadRoot("..", []string{".", "./internal/std"})
+	require.NoError(t, err)
+	tree, err := gotree.Tree(root, gotree.WithMaxLevel(1), gotree.WithNoStd(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+. ./internal/std
+├── github.com/MaruHyl/gotree
+└── github.com/MaruHyl/gotree/internal/std
+`, "\n"+tree)
+	// a single package is the root itself
+	root, err = loadRoot("..", []string{"./internal/std"})
+	require.NoError(t, err)
+	require.Equal(t, "github.com/MaruHyl/gotree/internal/std", root.Name())
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	diffCmd.Flags().BoolVar(&all, "all", false, "Print the whole merged tree instead of the changed deps only")
+	cmd.AddCommand(diffCmd)
+}
+
+var all bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> [new] [-- packages]",
+	Short: "Show the deps added, removed or changed between two graphs.",
+	Long: `Show the deps added, removed or changed between two graphs.
+
+Old and new are json files saved with --format json, or git revisions
+checked out into a temporary worktree, new defaults to the working tree.
+Packages after -- are loaded for both revisions.
+
+Deps are marked with + if added, - if removed and ~ if they moved between
+direct and indirect deps or changed module version. Only the paths to
+changed deps are printed, unless --all or --pattern is given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		n := len(args)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			n = dash
+		}
+		if n < 1 || n > 2 {
+			return usageError("expect an old and an optional new graph, got %d", n)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if input != "" {
+			return usageError("--input can not be used with diff")
+		}
+		opts, err := buildOptions()
+		if err != nil {
+			return err
+		}
+		refs, patterns := args, []string(nil)
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			refs, patterns = args[:dash], args[dash:]
+		}
+		old, err := loadGraph(refs[0], patterns)
+		if err != nil {
+			return err
+		}
+		newRef := ""
+		if len(refs) > 1 {
+			newRef = refs[1]
+		}
+		new, err := loadGraph(newRef, patterns)
+		if err != nil {
+			return err
+		}
+		root := gotree.Diff(old, new)
+		if !all && pattern == "" {
+			changed, err := changedFilter(root)
+			if err != nil {
+				return failure("build diff error: %v", err)
+			}
+			opts = append(opts, gotree.WithFilter(changed))
+		}
+		return render(root, opts)
+	},
+}
+
+// load one side of a diff, from a json file, a git revision or the
+// working tree if ref is empty
+func loadGraph(ref string, patterns []string) (gotree.Dep, error) {
+	if ref == "" {
+		return loadRoot("", patterns)
+	}
+	if fi, err := os.Stat(ref); err == nil && !fi.IsDir() {
+		if len(patterns) > 0 {
+			return nil, usageError("packages can not be given for a json file: %s", ref)
+		}
+		root, err := readInput(ref)
+		if err != nil {
+			return nil, err
+		}
+		if modules {
+			root = gotree.Modules(root)
+		}
+		return root, nil
+	}
+	dir, cleanup, err := checkout(ref)
+	if err != nil {
+		return nil, loadError(err)
+	}
+	defer cleanup()
+	root, err := loadRoot(dir, patterns)
+	if err != nil {
+		return nil, loadError(fmt.Errorf("%s: %v", ref, err))
+	}
+	return root, nil
+}
+
+// check out rev into a temporary git worktree, returning the directory
+// matching the current one in it
+func checkout(rev string) (string, func(), error) {
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "gotree-")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := git("worktree", "add", "--detach", tmp, rev); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, err
+	}
+	cleanup := func() {
+		git("worktree", "remove", "--force", tmp)
+		os.RemoveAll(tmp)
+	}
+	return filepath.Join(tmp, prefix), cleanup, nil
+}
+
+// run git in the current directory, returning its trimmed output
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("git", args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// filter matching the changed deps of a diff
+func changedFilter(root gotree.Dep) (gotree.Filter, error) {
+	g, err := gotree.Build(root, gotree.WithDedup(true))
+	if err != nil {
+		return nil, err
+	}
+	changed := make(nameFilter)
+	g.Walk(func(n *gotree.Node, path []*gotree.Node) bool {
+		if n.Change != "" {
+			changed[n.Name] = true
+		}
+		return true
+	}, nil)
+	return changed, nil
+}
+
+// nameFilter matches the deps of the given names
+type nameFilter map[string]bool
+
+func (f nameFilter) Filter(name string) bool {
+	return !f[name]
+}
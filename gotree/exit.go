@@ -0,0 +1,46 @@
+package main
+
+import "fmt"
+
+// Exit codes of gotree
+const (
+	// the tree could not be printed
+	exitFailure = 1
+	// bad flags or arguments
+	exitUsage = 2
+	// packages could not be loaded
+	exitLoad = 3
+	// the graph violates a policy flag
+	exitPolicy = 4
+)
+
+// exitError is an error which gotree exits with a specific code on
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func usageError(format string, args ...interface{}) error {
+	return &exitError{exitUsage, fmt.Errorf(format, args...)}
+}
+
+func loadError(err error) error {
+	return &exitError{exitLoad, err}
+}
+
+func failure(format string, args ...interface{}) error {
+	return &exitError{exitFailure, fmt.Errorf(format, args...)}
+}
+
+// exit code of an error returned by a command, errors reported by cobra
+// itself are about flags or arguments
+func exitCode(err error) int {
+	if e, ok := err.(*exitError); ok {
+		return e.code
+	}
+	return exitUsage
+}
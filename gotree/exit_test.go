@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, exitFailure, exitCode(failure("build tree error: %v", errors.New("x"))))
+	require.Equal(t, exitUsage, exitCode(usageError("unknown format: %s", "x")))
+	require.Equal(t, exitLoad, exitCode(loadError(errors.New("x"))))
+	require.Equal(t, exitPolicy, exitCode(&exitError{exitPolicy, errors.New("x")}))
+	// errors reported by cobra itself
+	require.Equal(t, exitUsage, exitCode(errors.New("unknown flag: --x")))
+}
+
+func TestExitCode_Command(t *testing.T) {
+	defer cmd.SetArgs(nil)
+	for _, c := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--no-such-flag"}, exitUsage},
+		{[]string{"--format", "svg"}, exitUsage},
+		{[]string{"why"}, exitUsage},
+		{[]string{"./nonexistent"}, exitLoad},
+		// errors of given packages win over --strict
+		{[]string{"--strict", "./nonexistent"}, exitLoad},
+	} {
+		// flags keep their value between executions
+		format, strict = "tree", false
+		cmd.SetArgs(c.args)
+		err := cmd.Execute()
+		require.Error(t, err, "%v", c.args)
+		require.Equal(t, c.code, exitCode(err), "%v: %v", c.args, err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	jsonenc "encoding/json"
+	"fmt"
+	"go/version"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cmd.AddCommand(goversionCmd)
+}
+
+var goversionCmd = &cobra.Command{
+	Use:   "goversion [packages]",
+	Short: "Show the Go version needed by the std symbols each package uses.",
+	Long: `Show the Go version needed by the std symbols each package uses.
+
+Every package in the graph is type-checked, and the newest std symbol it
+references gives the Go version it needs. Packages are listed newest first,
+followed by the version needed by the whole graph and the go directive of
+the main module.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if input != "" || len(platforms) > 0 {
+			return usageError("--input and --platforms can not be used with goversion")
+		}
+		if f := outputFormat(); f != "tree" && f != "json" {
+			return usageError("goversion only supports tree and json formats")
+		}
+		l := newLoader("")
+		l.Types = true
+		pkgs, err := l.Load(args...)
+		if err != nil {
+			return loadError(err)
+		}
+		recordRootErrors(pkgs)
+		versions := gotree.GoVersions(pkgs)
+		goMod := ""
+		for _, pkg := range pkgs {
+			if pkg.Module != nil && pkg.Module.GoVersion != "" {
+				goMod = pkg.Module.GoVersion
+				break
+			}
+		}
+		if err := printGoVersions(versions, goMod); err != nil {
+			return err
+		}
+		roots := gotree.NewPackageDeps(pkgs)
+		return checkErrors(forest{name: strings.Join(args, " "), deps: roots})
+	},
+}
+
+// go versions in json
+type goVersions struct {
+	Packages []gotree.GoVersion
+	Minimum  *gotree.GoVersion `json:",omitempty"`
+	GoMod    string            `json:",omitempty"`
+}
+
+func printGoVersions(versions []gotree.GoVersion, goMod string) error {
+	var min *gotree.GoVersion
+	if len(versions) > 0 {
+		min = &versions[0]
+	}
+	if outputFormat() == "json" {
+		b, err := jsonenc.MarshalIndent(goVersions{versions, min, goMod}, "", " ")
+		if err != nil {
+			return failure("build json error: %v", err)
+		}
+		fmt.Fprintf(os.Stdout, "%s\n", b)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, v := range versions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Package, v, v.Symbol)
+	}
+	tw.Flush()
+	if noReport || min == nil {
+		return nil
+	}
+	fmt.Printf("needs %s", min)
+	if min.Symbol != "" {
+		fmt.Printf(" (%s in %s)", min.Symbol, min.Package)
+	}
+	if goMod != "" {
+		fmt.Printf(", go.mod declares go %s", goMod)
+		switch lang := version.Lang("go" + goMod); {
+		case lang == "":
+			fmt.Print(" which is not a valid go version")
+		case version.Compare(lang, fmt.Sprintf("go1.%d", min.Minor)) < 0:
+			fmt.Print(" which is older")
+		}
+	}
+	fmt.Println()
+	return nil
+}
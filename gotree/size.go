@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/MaruHyl/gotree"
+)
+
+func init() {
+	cmd.Flags().BoolVar(&size, "size", false,
+		"Build the packages and print the size of every dep in the binary, and with the deps reachable from it")
+	cmd.Flags().StringVar(&binary, "binary", "", "Read the sizes of --size from an existing binary instead of building one")
+	cmd.Flags().StringVar(&nm, "nm", "", "Read the sizes of --size from the output of go tool nm -size, - for stdin")
+	cmd.Flags().BoolVar(&sortSize, "sort-size", false, "Sort deps by size with the deps reachable from them, largest first")
+}
+
+var size bool
+var binary string
+var nm string
+var sortSize bool
+
+// whether sizes are printed, implied by the other size flags
+func sizeMode() bool {
+	return size || binary != "" || nm != "" || sortSize
+}
+
+// sizes of the packages matched by patterns, from the binary or nm output
+// given, or built in a temporary directory
+func loadSizes(patterns []string) (map[string]int64, error) {
+	switch {
+	case binary != "" && nm != "":
+		return nil, usageError("--binary can not be used with --nm")
+	case nm != "":
+		if nm == "-" && input == "-" {
+			return nil, usageError("--nm and --input can not both read stdin")
+		}
+		r := os.Stdin
+		if nm != "-" {
+			f, err := os.Open(nm)
+			if err != nil {
+				return nil, loadError(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		sizes, err := gotree.ReadSizes(r)
+		if err != nil {
+			return nil, loadError(err)
+		}
+		return sizes, nil
+	case binary != "":
+		sizes, err := gotree.BinarySizes(binary)
+		if err != nil {
+			return nil, loadError(err)
+		}
+		return sizes, nil
+	case input != "":
+		return nil, usageError("--size can not build a graph read from --input, use --binary or --nm")
+	case len(platforms) > 0:
+		return nil, usageError("--size can not be used with --platforms")
+	}
+	tmp, err := os.MkdirTemp("", "gotree-")
+	if err != nil {
+		return nil, failure("create temp dir error: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	out := filepath.Join(tmp, "main")
+	if err := newLoader("").Build(out, patterns...); err != nil {
+		return nil, loadError(err)
+	}
+	sizes, err := gotree.BinarySizes(out)
+	if err != nil {
+		return nil, loadError(err)
+	}
+	return sizes, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	jsonenc "encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cmd.AddCommand(weightCmd)
+}
+
+var weightCmd = &cobra.Command{
+	Use:   "weight [packages]",
+	Short: "Rank direct deps by the deps they bring in.",
+	Long: `Rank direct deps by the deps they bring in.
+
+For every direct dep, exclusive counts the packages which would be removed
+along with it, those only reachable through it, and total every package
+reachable from it. Modules are counted the same way, a module is exclusive
+if all of its packages are. Deps are listed heaviest first.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if f := outputFormat(); f != "tree" && f != "json" {
+			return usageError("weight only supports tree and json formats")
+		}
+		opts, err := buildOptions()
+		if err != nil {
+			return err
+		}
+		root, err := loadRoot("", args)
+		if err != nil {
+			return err
+		}
+		weights, err := gotree.Weights(root, opts...)
+		if err != nil {
+			return failure("build weights error: %v", err)
+		}
+		if err := printWeights(weights); err != nil {
+			return err
+		}
+		return checkErrors(root)
+	},
+}
+
+func printWeights(weights []gotree.Weight) error {
+	if outputFormat() == "json" {
+		if weights == nil {
+			weights = []gotree.Weight{}
+		}
+		b, err := jsonenc.MarshalIndent(weights, "", " ")
+		if err != nil {
+			return failure("build json error: %v", err)
+		}
+		fmt.Fprintf(os.Stdout, "%s\n", b)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEP\tEXCLUSIVE\tTOTAL\tEXCLUSIVE MODULES\tTOTAL MODULES")
+	for _, w := range weights {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", w.Name, w.Exclusive, w.Total, w.ExclusiveModules, w.TotalModules)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	whyCmd.Flags().IntVarP(&limit, "limit", "n", defaultLimit, "Only show the shortest n import paths, 0 for all of them")
+	cmd.AddCommand(whyCmd)
+}
+
+var limit int
+
+// the number of import paths grows exponentially with the graph
+const defaultLimit = 10
+
+var whyCmd = &cobra.Command{
+	Use:   "why <package> [packages]",
+	Short: "Show every import path leading to a package.",
+	Long: `Show every import path leading to a package.
+
+The import paths are printed from the root to the given package, merged
+into a tree. Only the shortest 10 are shown by default, use --limit to
+change it, --limit 0 shows them all, which may take long in a large graph.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := buildOptions()
+		if err != nil {
+			return err
+		}
+		root, err := loadRoot("", args[1:])
+		if err != nil {
+			return err
+		}
+		paths := gotree.Why(root, args[0], limit)
+		if len(paths) == 0 {
+			return failure("%s does not import %s", root.Name(), args[0])
+		}
+		// paths sharing a dep must not be cut by dedup
+		err = render(gotree.NewPathsDep(paths), append(opts, gotree.WithNoReport(true), gotree.WithDedup(false)))
+		if err != nil {
+			return err
+		}
+		if !noReport && outputFormat() == "tree" {
+			if limit > 0 && len(paths) == limit {
+				fmt.Printf("%d shortest import paths, --limit 0 shows them all\n", len(paths))
+			} else {
+				fmt.Printf("%d import paths\n", len(paths))
+			}
+		}
+		return checkErrors(root)
+	},
+}
@@ -0,0 +1,148 @@
+package gotree
+
+import (
+	"go/types"
+	"sort"
+	"strconv"
+
+	"github.com/MaruHyl/gotree/internal/std"
+	"golang.org/x/tools/go/packages"
+)
+
+// GoVersion is the Go version a package needs for the std symbols it
+// references. Minor is the minor version of Go 1 which introduced Symbol,
+// the newest of them, Symbol is empty if they are all in Go 1.0.
+type GoVersion struct {
+	Package string
+	Minor   int
+	Symbol  string `json:",omitempty"`
+}
+
+func (v GoVersion) String() string {
+	return "go1." + strconv.Itoa(v.Minor)
+}
+
+// Get the Go version needed by every non std package in the graph of
+// pkgs, loaded with Loader.Types, newest first
+func GoVersions(pkgs []*packages.Package) []GoVersion {
+	byPath := make(map[string]GoVersion)
+	owners := make(fieldOwners)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.TypesInfo == nil || (pkgDep{pkg: pkg}).Std() {
+			return
+		}
+		v := goVersion(pkg, owners)
+		// test variants share the path of the package they test
+		if old, ok := byPath[v.Package]; ok && !newer(v, old) {
+			return
+		}
+		byPath[v.Package] = v
+	})
+	versions := make([]GoVersion, 0, len(byPath))
+	for _, v := range byPath {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Minor != versions[j].Minor {
+			return versions[i].Minor > versions[j].Minor
+		}
+		return versions[i].Package < versions[j].Package
+	})
+	return versions
+}
+
+// the newest std symbol referenced by pkg
+func goVersion(pkg *packages.Package, owners fieldOwners) GoVersion {
+	v := GoVersion{Package: pkg.PkgPath}
+	for _, obj := range pkg.TypesInfo.Uses {
+		sym, minor, ok := stdSymbol(obj, owners)
+		if !ok {
+			continue
+		}
+		if u := (GoVersion{Minor: minor, Symbol: sym}); minor > 0 && newer(u, v) {
+			v.Minor, v.Symbol = u.Minor, u.Symbol
+		}
+	}
+	return v
+}
+
+// whether a needs a newer Go than b, the smallest symbol wins on a tie
+// to get the same result on every run
+func newer(a, b GoVersion) bool {
+	if a.Minor != b.Minor {
+		return a.Minor > b.Minor
+	}
+	return b.Symbol == "" || (a.Symbol != "" && a.Symbol < b.Symbol)
+}
+
+// qualified name and version of a std symbol, methods and fields are
+// named after their type, e.g. time.Time.Compare
+func stdSymbol(obj types.Object, owners fieldOwners) (string, int, bool) {
+	if obj == nil || obj.Pkg() == nil {
+		return "", 0, false
+	}
+	name := obj.Name()
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := obj.Origin().Type().(*types.Signature).Recv(); recv != nil {
+			// methods of interfaces without name are not in the table
+			t := recv.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			named, ok := t.(*types.Named)
+			if !ok {
+				return "", 0, false
+			}
+			name = named.Origin().Obj().Name() + "." + name
+		} else if obj.Parent() != obj.Pkg().Scope() {
+			return "", 0, false
+		}
+	case *types.Var:
+		if obj.IsField() {
+			owner := owners.owner(obj)
+			if owner == "" {
+				return "", 0, false
+			}
+			name = owner + "." + name
+		} else if obj.Parent() != obj.Pkg().Scope() {
+			return "", 0, false
+		}
+	default:
+		if obj.Parent() != obj.Pkg().Scope() {
+			return "", 0, false
+		}
+	}
+	path := obj.Pkg().Path()
+	minor, ok := std.StdLib[path][name]
+	if !ok {
+		return "", 0, false
+	}
+	return path + "." + name, minor, true
+}
+
+// fieldOwners memoizes the package level struct type declaring each
+// field, by package
+type fieldOwners map[*types.Package]map[*types.Var]string
+
+// name of the struct type declaring field, empty if it has none
+func (o fieldOwners) owner(field *types.Var) string {
+	owners, ok := o[field.Pkg()]
+	if !ok {
+		owners = make(map[*types.Var]string)
+		scope := field.Pkg().Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+				for i := 0; i < st.NumFields(); i++ {
+					owners[st.Field(i)] = name
+				}
+			}
+		}
+		o[field.Pkg()] = owners
+	}
+	return owners[field.Origin()]
+}
@@ -0,0 +1,111 @@
+package gotree_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+// type-check src as the package at path, importing the types of the
+// given imports or of std packages
+func newTypedPackage(t *testing.T, path, src string, imports ...*packages.Package) *packages.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path+".go", src, 0)
+	require.NoError(t, err)
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		for _, i := range imports {
+			if i.PkgPath == path && i.Types != nil {
+				return i.Types, nil
+			}
+		}
+		return importer.Default().Import(path)
+	})}
+	tpkg, err := conf.Check(path, fset, []*ast.File{f}, info)
+	require.NoError(t, err)
+	pkg := newPackage(path, path, imports...)
+	pkg.Module = &packages.Module{Path: path}
+	pkg.Types = tpkg
+	pkg.TypesInfo = info
+	return pkg
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func TestGoVersions(t *testing.T) {
+	q := newTypedPackage(t, "q", `package q
+
+import (
+	"strings"
+	"unsafe"
+)
+
+func Bytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+func Upper(s string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(s))
+	return b.String()
+}
+`)
+	old := newTypedPackage(t, "old", `package old
+
+import "fmt"
+
+func Print() { fmt.Println() }
+`)
+	// methods and fields are looked up by their type
+	m := newTypedPackage(t, "m", `package m
+
+import (
+	"bytes"
+	"time"
+)
+
+func Since(t time.Time) int { return time.Now().Compare(t) }
+
+func Buffer() []byte {
+	var b bytes.Buffer
+	return b.AvailableBuffer()
+}
+`)
+	f := newTypedPackage(t, "f", `package f
+
+import "net/url"
+
+func NoHost(u *url.URL) bool { return u.OmitHost }
+`)
+	p := newTypedPackage(t, "p", `package p
+
+import (
+	"slices"
+	"sort"
+)
+
+func Sort(s []int) {
+	slices.Sort(s)
+	sort.Ints(s)
+}
+`, q, old, m, f)
+	require.Equal(t, []gotree.GoVersion{
+		{Package: "m", Minor: 21, Symbol: "bytes.Buffer.AvailableBuffer"},
+		{Package: "p", Minor: 21, Symbol: "slices.Sort"},
+		{Package: "q", Minor: 20, Symbol: "unsafe.StringData"},
+		{Package: "f", Minor: 19, Symbol: "net/url.URL.OmitHost"},
+		{Package: "old", Minor: 0},
+	}, gotree.GoVersions([]*packages.Package{p}))
+	require.Equal(t, "go1.21", gotree.GoVersions([]*packages.Package{p})[0].String())
+}
@@ -0,0 +1,48 @@
+package gotree
+
+import "sort"
+
+// graph is a dep graph indexed by name, each dep is visited once
+type graph struct {
+	root  string
+	deps  map[string]Dep
+	edges map[string][]string
+}
+
+func newGraph(d Dep) *graph {
+	g := &graph{
+		root:  d.Name(),
+		deps:  make(map[string]Dep),
+		edges: make(map[string][]string),
+	}
+	var dfs func(d Dep)
+	dfs = func(d Dep) {
+		name := d.Name()
+		if _, ok := g.deps[name]; ok {
+			return
+		}
+		g.deps[name] = d
+		_deps := d.Deps()
+		names := make([]string, 0, len(_deps))
+		for _, _dep := range _deps {
+			names = append(names, _dep.Name())
+		}
+		sort.Strings(names)
+		g.edges[name] = names
+		for _, _dep := range _deps {
+			dfs(_dep)
+		}
+	}
+	dfs(d)
+	return g
+}
+
+// names of all deps in the graph, sorted
+func (g *graph) names() []string {
+	names := make([]string, 0, len(g.deps))
+	for name := range g.deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
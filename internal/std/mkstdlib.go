@@ -1,8 +1,9 @@
 // +build ignore
 
 // mkstdlib generates the zstdlib.go file, containing the Go standard
-// library API symbols. It's baked into the binary to avoid scanning
-// GOPATH in the common case.
+// library API symbols, methods and fields, and the minor version of Go 1
+// which introduced them.
+// It's baked into the binary to avoid scanning GOPATH in the common case.
 package main
 
 import (
@@ -10,16 +11,19 @@ import (
 	"bytes"
 	"fmt"
 	"go/format"
+	"go/types"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 func mustOpen(name string) io.Reader {
@@ -36,7 +40,42 @@ func api(base string) string {
 
 var sym = regexp.MustCompile(`^pkg (\S+).*?, (?:var|func|type|const) ([A-Z]\w*)`)
 
-var unsafeSyms = map[string]bool{"Alignof": true, "ArbitraryType": true, "Offsetof": true, "Pointer": true, "Sizeof": true}
+// methods, recorded as Type.Method
+var method = regexp.MustCompile(`^pkg (\S+).*?, method \(\*?([A-Z]\w*)(?:\[[^]]*\])?\) ([A-Z]\w*)`)
+
+// fields of structs and methods of interfaces, recorded as Type.Member
+var member = regexp.MustCompile(`^pkg (\S+).*?, type ([A-Z]\w*) (?:struct|interface), (?:embedded \*?(?:\w+\.)?)?([A-Z]\w*)`)
+
+// The api files do not list the unsafe package.
+var unsafeSyms = map[string]int{
+	"Add": 17, "Alignof": 0, "ArbitraryType": 0, "Offsetof": 0, "Pointer": 0, "Sizeof": 0,
+	"Slice": 17, "SliceData": 20, "String": 20, "StringData": 20,
+}
+
+// go1.txt, go1.1.txt... go1.N.txt by minor version
+func apiFiles() []string {
+	files, err := filepath.Glob(api("go1*.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return minor(files[i]) < minor(files[j])
+	})
+	return files
+}
+
+// minor version of an api file, 0 for go1.txt
+func minor(file string) int {
+	v := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "go1"), ".txt")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(v, "."))
+	if err != nil {
+		log.Fatalf("unexpected api file %s", file)
+	}
+	return n
+}
 
 func main() {
 	var buf bytes.Buffer
@@ -45,55 +84,67 @@ func main() {
 	}
 	outf("// Code generated by mkstdlib.go. DO NOT EDIT.\n\n")
 	outf("package std\n")
-	outf("var StdLib = map[string]map[string]bool{\n")
-	f := io.MultiReader(
-		mustOpen(api("go1.txt")),
-		mustOpen(api("go1.1.txt")),
-		mustOpen(api("go1.2.txt")),
-		mustOpen(api("go1.3.txt")),
-		mustOpen(api("go1.4.txt")),
-		mustOpen(api("go1.5.txt")),
-		mustOpen(api("go1.6.txt")),
-		mustOpen(api("go1.7.txt")),
-		mustOpen(api("go1.8.txt")),
-		mustOpen(api("go1.9.txt")),
-		mustOpen(api("go1.10.txt")),
-		mustOpen(api("go1.11.txt")),
-		mustOpen(api("go1.12.txt")),
-
-		// The API of the syscall/js package needs to be computed explicitly,
-		// because it's not included in the GOROOT/api/go1.*.txt files at this time.
-		syscallJSAPI(),
-	)
-	sc := bufio.NewScanner(f)
-
-	pkgs := map[string]map[string]bool{
+	outf("// StdLib maps std packages to their exported symbols, and each symbol to\n")
+	outf("// the minor version of Go 1 which introduced it. Methods, struct fields\n")
+	outf("// and interface methods are recorded as Type.Name\n")
+	outf("var StdLib = map[string]map[string]int{\n")
+
+	pkgs := map[string]map[string]int{
 		"unsafe": unsafeSyms,
 	}
 	paths := []string{"unsafe"}
-
-	for sc.Scan() {
-		l := sc.Text()
-		has := func(v string) bool { return strings.Contains(l, v) }
-		if has("struct, ") || has("interface, ") || has(", method (") {
-			continue
+	add := func(path, sym string, minor int) {
+		if _, ok := pkgs[path]; !ok {
+			pkgs[path] = map[string]int{}
+			paths = append(paths, path)
 		}
-		if m := sym.FindStringSubmatch(l); m != nil {
-			path, sym := m[1], m[2]
+		// a symbol may be listed again for other platforms
+		if _, ok := pkgs[path][sym]; !ok {
+			pkgs[path][sym] = minor
+		}
+	}
 
-			if _, ok := pkgs[path]; !ok {
-				pkgs[path] = map[string]bool{}
-				paths = append(paths, path)
+	for _, file := range apiFiles() {
+		sc := bufio.NewScanner(mustOpen(file))
+		for sc.Scan() {
+			l := sc.Text()
+			if m := method.FindStringSubmatch(l); m != nil {
+				add(m[1], m[2]+"."+m[3], minor(file))
+				continue
 			}
-			pkgs[path][sym] = true
+			if m := member.FindStringSubmatch(l); m != nil {
+				add(m[1], m[2]+"."+m[3], minor(file))
+			}
+			// interfaces with unexported methods are only listed along
+			// with their methods, as type X interface, M()
+			if m := sym.FindStringSubmatch(l); m != nil {
+				add(m[1], m[2], minor(file))
+			}
+		}
+		if err := sc.Err(); err != nil {
+			log.Fatal(err)
 		}
 	}
-	if err := sc.Err(); err != nil {
-		log.Fatal(err)
+	// The API of the syscall/js package needs to be computed explicitly,
+	// because it's not included in the GOROOT/api/go1.*.txt files, nor are
+	// the versions of its symbols, see syscallJSSince.
+	jsSyms := make(map[string]bool)
+	for _, sym := range syscallJSAPI() {
+		minor, ok := syscallJSSince[sym]
+		if !ok {
+			minor = 11
+		}
+		add("syscall/js", sym, minor)
+		jsSyms[sym] = true
+	}
+	for sym := range syscallJSSince {
+		if !jsSyms[sym] {
+			log.Fatalf("syscall/js.%s is not in the package anymore", sym)
+		}
 	}
 	sort.Strings(paths)
 	for _, path := range paths {
-		outf("\t%q: map[string]bool{\n", path)
+		outf("\t%q: map[string]int{\n", path)
 		pkg := pkgs[path]
 		var syms []string
 		for sym := range pkg {
@@ -101,7 +152,7 @@ func main() {
 		}
 		sort.Strings(syms)
 		for _, sym := range syms {
-			outf("\t\t%q: true,\n", sym)
+			outf("\t\t%q: %d,\n", sym, pkg[sym])
 		}
 		outf("},\n")
 	}
@@ -116,17 +167,63 @@ func main() {
 	}
 }
 
-// syscallJSAPI returns the API of the syscall/js package.
-// It's computed from the contents of $(go env GOROOT)/src/syscall/js.
-func syscallJSAPI() io.Reader {
-	var exeSuffix string
-	if runtime.GOOS == "windows" {
-		exeSuffix = ".exe"
+// syscallJSSince holds the minor version of the symbols of syscall/js added
+// after the package itself in Go 1.11, from the release notes since the API
+// check skips js/wasm. New symbols of the package must be added here.
+var syscallJSSince = map[string]int{
+	"Func":              12,
+	"Func.Release":      12,
+	"Func.Value":        12,
+	"FuncOf":            12,
+	"Value.Truthy":      12,
+	"CopyBytesToGo":     13,
+	"CopyBytesToJS":     13,
+	"Value.Delete":      14,
+	"Value.Equal":       14,
+	"Value.IsNaN":       14,
+	"Value.IsNull":      14,
+	"Value.IsUndefined": 14,
+}
+
+// syscallJSAPI returns the exported symbols, methods and fields of the
+// syscall/js package, type-checked from $(go env GOROOT)/src/syscall/js.
+func syscallJSAPI() []string {
+	cfg := &packages.Config{
+		// export data of the toolchain may be too new, check it from source
+		Mode: packages.LoadAllSyntax,
+		Env:  append(os.Environ(), "GOOS=js", "GOARCH=wasm"),
 	}
-	cmd := exec.Command("go"+exeSuffix, "run", "cmd/api", "-contexts", "js-wasm", "syscall/js")
-	out, err := cmd.Output()
+	pkgs, err := packages.Load(cfg, "syscall/js")
 	if err != nil {
 		log.Fatalln(err)
 	}
-	return bytes.NewReader(out)
+	if packages.PrintErrors(pkgs) > 0 {
+		log.Fatalln("can not load syscall/js")
+	}
+	var syms []string
+	scope := pkgs[0].Types.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		syms = append(syms, name)
+		named, ok := obj.Type().(*types.Named)
+		if _, isType := obj.(*types.TypeName); !isType || !ok {
+			continue
+		}
+		for i := 0; i < named.NumMethods(); i++ {
+			if m := named.Method(i); m.Exported() {
+				syms = append(syms, name+"."+m.Name())
+			}
+		}
+		if st, ok := named.Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				if f := st.Field(i); f.Exported() {
+					syms = append(syms, name+"."+f.Name())
+				}
+			}
+		}
+	}
+	return syms
 }
@@ -0,0 +1,80 @@
+package gotree
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+const mermaidClassDefs = `	classDef root fill:#add8e6,stroke-width:2px
+	classDef direct fill:#d3d3d3
+	classDef indirect fill:#ffffff
+	classDef matched color:#ff0000,stroke:#ff0000
+`
+
+// Get dep graph(mermaid flowchart), every dep appears once
+func Mermaid(d Dep, options ...Option) (string, error) {
+	if d == nil {
+		return "", nil
+	}
+	opts, err := buildOpts(options...)
+	if err != nil {
+		return "", err
+	}
+	// every dep appears once in dedup mode, the traversal can only fail
+	// on cancellation
+	opts.dedup = true
+	g, _ := buildGraph(context.Background(), d, opts)
+	nodes, edges := g.Nodes, g.Edges
+	//
+	ids := mermaidIDs(nodes)
+	sb := new(strings.Builder)
+	sb.WriteString("flowchart LR\n")
+	sb.WriteString(mermaidClassDefs)
+	var matched []string
+	for _, n := range nodes {
+		fmt.Fprintf(sb, "\t%s[\"%s\"]:::%s\n",
+			ids[n.Name], strings.Replace(n.label(opts), `"`, "#quot;", -1), n.Type)
+		if n.Matched && highlight(opts) {
+			matched = append(matched, ids[n.Name])
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(sb, "\t%s --> %s\n", ids[e.From], ids[e.To])
+	}
+	if len(matched) > 0 {
+		fmt.Fprintf(sb, "\tclass %s matched\n", strings.Join(matched, ","))
+	}
+	return sb.String(), nil
+}
+
+// ids of the nodes of a mermaid flowchart, derived from dep names rather
+// than from their order, so adding or removing a dep does not change the
+// ids of the others. Names which only differ by punctuation are told apart
+// by their hash, as is the end keyword of mermaid.
+func mermaidIDs(nodes []GraphNode) map[string]string {
+	names := make(map[string][]string)
+	for _, n := range nodes {
+		id := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, n.Name)
+		names[id] = append(names[id], n.Name)
+	}
+	ids := make(map[string]string, len(nodes))
+	for id, _names := range names {
+		for _, name := range _names {
+			if len(_names) > 1 || strings.EqualFold(id, "end") {
+				h := fnv.New32a()
+				h.Write([]byte(name))
+				ids[name] = fmt.Sprintf("%s_%08x", id, h.Sum32())
+			} else {
+				ids[name] = id
+			}
+		}
+	}
+	return ids
+}
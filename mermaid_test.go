@@ -0,0 +1,42 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMermaid(t *testing.T) {
+	mermaid, err := gotree.Mermaid(nil)
+	require.NoError(t, err)
+	require.Equal(t, "", mermaid)
+
+	root := &mockDep{name: "m", deps: []gotree.Dep{
+		&mockDep{name: "x/a", deps: []gotree.Dep{&mockDep{name: "x_a"}}},
+		&mockDep{name: "fmt"},
+	}}
+	mermaid, err = gotree.Mermaid(root,
+		gotree.WithFilter(fixedFilter{"x_a"}), gotree.WithMaxLevel(2))
+	require.NoError(t, err)
+	require.Equal(t, `flowchart LR
+	classDef root fill:#add8e6,stroke-width:2px
+	classDef direct fill:#d3d3d3
+	classDef indirect fill:#ffffff
+	classDef matched color:#ff0000,stroke:#ff0000
+	m["m"]:::root
+	x_a_dacc8f5b["x/a"]:::direct
+	x_a_bb44b14b["x_a"]:::indirect
+	m --> x_a_dacc8f5b
+	x_a_dacc8f5b --> x_a_bb44b14b
+	class x_a_bb44b14b matched
+`, mermaid)
+	// a keyword of mermaid
+	mermaid, err = gotree.Mermaid(&mockDep{name: "m", deps: []gotree.Dep{&mockDep{name: "end"}}})
+	require.NoError(t, err)
+	require.Contains(t, mermaid, `
+	end_6a8e75aa["end"]:::direct
+	m["m"]:::root
+	m --> end_6a8e75aa
+`)
+}
@@ -0,0 +1,88 @@
+package gotree
+
+import "sort"
+
+// Group the deps of d by module, the deps of a module are the modules
+// imported by its packages. Deps without module(e.g. std packages) are
+// skipped, unless d itself has none(e.g. several roots grouped together).
+func Modules(d Dep) Dep {
+	if d == nil {
+		return nil
+	}
+	g := newGraph(d)
+	moduleOf := func(name string) *Module {
+		if md, ok := g.deps[name].(ModuleDep); ok {
+			return md.Module()
+		}
+		return nil
+	}
+	keyOf := func(name string) string {
+		if m := moduleOf(name); m != nil {
+			return m.Path
+		}
+		if name == g.root {
+			return name
+		}
+		return ""
+	}
+	modules := make(map[string]*moduleDep)
+	imports := make(map[string]map[string]bool)
+	for _, name := range g.names() {
+		key := keyOf(name)
+		if key == "" {
+			continue
+		}
+		if _, ok := modules[key]; !ok {
+			modules[key] = &moduleDep{name: key, module: moduleOf(name)}
+			imports[key] = make(map[string]bool)
+		}
+		// look through deps without module for the modules they import
+		visited := make(map[string]bool)
+		stack := append([]string(nil), g.edges[name]...)
+		for len(stack) > 0 {
+			_name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _key := keyOf(_name); _key != "" {
+				if _key != key {
+					imports[key][_key] = true
+				}
+				continue
+			}
+			if !visited[_name] {
+				visited[_name] = true
+				stack = append(stack, g.edges[_name]...)
+			}
+		}
+	}
+	for key, md := range modules {
+		for _key := range imports[key] {
+			md.deps = append(md.deps, modules[_key])
+		}
+		sort.Slice(md.deps, func(i, j int) bool {
+			return md.deps[i].name < md.deps[j].name
+		})
+	}
+	return modules[keyOf(g.root)]
+}
+
+type moduleDep struct {
+	name   string
+	module *Module
+	deps   []*moduleDep
+}
+
+func (d *moduleDep) Name() string {
+	return d.name
+}
+
+func (d *moduleDep) Deps() []Dep {
+	deps := make([]Dep, 0, len(d.deps))
+	for _, _dep := range d.deps {
+		deps = append(deps, _dep)
+	}
+	return deps
+}
+
+func (d *moduleDep) Module() *Module {
+	return d.module
+}
@@ -0,0 +1,77 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModules(t *testing.T) {
+	require.Nil(t, gotree.Modules(nil))
+
+	main := &gotree.Module{Path: "m"}
+	x := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	y := &gotree.Module{Path: "y", Version: "v0.1.0", Indirect: true,
+		Replace: &gotree.Module{Path: "../y"}}
+	// x/b imports y/a which imports x/a through std,
+	// so x and y import each other
+	xa := &mockModuleDep{mockDep{name: "x/a"}, x}
+	ya := &mockModuleDep{mockDep{name: "y/a", deps: []gotree.Dep{
+		&mockDep{name: "fmt", deps: []gotree.Dep{xa}},
+	}}, y}
+	xb := &mockModuleDep{mockDep{name: "x/b", deps: []gotree.Dep{ya}}, x}
+	root := &mockModuleDep{mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "m/a", deps: []gotree.Dep{xb}}, main},
+		&mockDep{name: "strings"},
+	}}, main}
+
+	tree, err := gotree.Tree(gotree.Modules(root), gotree.WithVersions(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+m
+└── x@v1.0.0
+    └── y@v0.1.0 => ../y // indirect
+        └── x@v1.0.0 (cycle)
+3 deps, 1 direct, 2 indirect (2 unique, 1 direct, 1 indirect), 2 modules, 1 cycles`, "\n"+tree)
+}
+
+func TestVersions(t *testing.T) {
+	x := &gotree.Module{Path: "x", Version: "v1.0.0",
+		Replace: &gotree.Module{Path: "z", Version: "v1.1.0"}}
+	root := &mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "x/a"}, x},
+	}}
+	tree, err := gotree.Tree(root, gotree.WithVersions(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+m
+└── x/a@v1.0.0 => z@v1.1.0
+`, "\n"+tree)
+
+	json, err := gotree.JSONTree(root, gotree.WithVersions(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "m",
+  "Matched": true,
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "x/a",
+    "Matched": true,
+    "Module": {
+     "Path": "x",
+     "Version": "v1.0.0",
+     "Replace": {
+      "Path": "z",
+      "Version": "v1.1.0"
+     }
+    }
+   }
+  ]
+ }
+]`, "\n"+json)
+}
@@ -9,11 +9,17 @@ var defaultFilter = nopFilter{}
 
 //
 type options struct {
-	maxLevel   int
-	noReport   bool
-	filter     Filter
-	noStd      bool
-	noInternal bool
+	maxLevel    int
+	noReport    bool
+	filter      Filter
+	noStd       bool
+	noInternal  bool
+	dedup       bool
+	clusters    bool
+	versions    bool
+	concurrency int
+	sizes       map[string]int64
+	sizeSort    bool
 }
 
 var defaultOptions = options{
@@ -77,3 +83,63 @@ func WithNoInternal(noInternal bool) Option {
 		return nil
 	}
 }
+
+// Print repeated deps once, later occurrences are marked with (*)
+// instead of being expanded again
+func WithDedup(dedup bool) Option {
+	return func(opts *options) error {
+		opts.dedup = dedup
+		return nil
+	}
+}
+
+// Group deps by module in graph output
+func WithModuleClusters(clusters bool) Option {
+	return func(opts *options) error {
+		opts.clusters = clusters
+		return nil
+	}
+}
+
+// Print the module version of deps which are ModuleDep
+func WithVersions(versions bool) Option {
+	return func(opts *options) error {
+		opts.versions = versions
+		return nil
+	}
+}
+
+// Fetch the deps of deps ahead of the traversal with at most workers
+// goroutines, and memoize them by name. Deps with the same name must
+// have the same deps. 0 fetches them on demand without memoizing them,
+// as some graphs have deps of the same name with different deps, e.g.
+// the import paths merged by NewPathsDep. On cancellation no new fetch
+// starts, but those in flight are awaited before the traversal returns.
+func WithConcurrency(workers int) Option {
+	return func(opts *options) error {
+		if workers < 0 {
+			return errors.New("workers must not be negative")
+		}
+		opts.concurrency = workers
+		return nil
+	}
+}
+
+// Annotate deps with their size by name, e.g. from BinarySizes, and the
+// cumulative size of the deps reachable from them. The symbols of
+// package main are counted for the root.
+func WithSizes(sizes map[string]int64) Option {
+	return func(opts *options) error {
+		opts.sizes = sizes
+		return nil
+	}
+}
+
+// Sort deps by cumulative size, largest first, instead of by name,
+// only used along with WithSizes
+func WithSizeSort(sizeSort bool) Option {
+	return func(opts *options) error {
+		opts.sizeSort = sizeSort
+		return nil
+	}
+}
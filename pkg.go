@@ -2,21 +2,243 @@ package gotree
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
-func LoadPackages() (*packages.Package, error) {
+// Loader loads packages to print, build context fields left empty
+// default to the current environment
+type Loader struct {
+	// Load test variants as well, see NewPackageDeps
+	Tests bool
+	// Target operating system and architecture
+	GOOS   string
+	GOARCH string
+	// Build tags
+	Tags []string
+	// Enable or disable cgo, nil keeps CGO_ENABLED
+	Cgo *bool
+	// Directory to load packages from, the current one if empty
+	Dir string
+	// Load the syntax and types of every package as well, see GoVersions
+	Types bool
+}
+
+// Load the packages matching the given patterns(the package in the
+// current directory if no pattern is given), one root per matched package
+func LoadPackages(patterns ...string) ([]*packages.Package, error) {
+	return Loader{}.Load(patterns...)
+}
+
+// Load the packages matching the given patterns(the package in the
+// current directory if no pattern is given), one root per matched package
+// and per test variant
+func (l Loader) Load(patterns ...string) ([]*packages.Package, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	mode := packages.LoadImports | packages.NeedModule
+	if l.Types {
+		mode |= packages.LoadAllSyntax
+	}
 	cfg := &packages.Config{
-		Mode: packages.LoadImports,
+		Mode:  mode,
+		Tests: l.Tests,
+		Env:   l.env(),
+		Dir:   l.Dir,
+	}
+	if len(l.Tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(l.Tags, ",")}
 	}
-	root, err := packages.Load(cfg, "")
+	roots, err := packages.Load(cfg, patterns...)
 	if err != nil {
 		return nil, fmt.Errorf("load packages error: %v", err)
 	}
-	if len(root) != 1 {
-		return nil, fmt.Errorf("unsupported packages number: %d", len(root))
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no packages match: %s", strings.Join(patterns, " "))
+	}
+	return roots, nil
+}
+
+// environment of the go tool, nil to use the current one
+func (l Loader) env() []string {
+	var env []string
+	if l.GOOS != "" {
+		env = append(env, "GOOS="+l.GOOS)
+	}
+	if l.GOARCH != "" {
+		env = append(env, "GOARCH="+l.GOARCH)
+	}
+	if l.Cgo != nil {
+		if *l.Cgo {
+			env = append(env, "CGO_ENABLED=1")
+		} else {
+			env = append(env, "CGO_ENABLED=0")
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	// later values take precedence
+	return append(os.Environ(), env...)
+}
+
+// Wrap a loaded package as a ModuleDep
+func NewPackageDep(pkg *packages.Package) Dep {
+	return pkgDep{pkg: pkg, symbols: newSymbolCache()}
+}
+
+// Wrap loaded packages as ModuleDep, test variants(loaded with
+// Loader.Tests) are merged into the package they test, and the returned
+// deps are TestDep telling what is only needed by tests.
+func NewPackageDeps(pkgs []*packages.Package) []Dep {
+	var roots []*packages.Package
+	variants := make(map[string][]*packages.Package)
+	for _, pkg := range pkgs {
+		// skip the generated test main
+		if pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		// test variants are identified as "p [p.test]" or "p_test [p.test]"
+		if i := strings.Index(pkg.ID, " ["); i >= 0 {
+			tested := strings.TrimSuffix(pkg.ID[i+len(" ["):], ".test]")
+			variants[tested] = append(variants[tested], pkg)
+			continue
+		}
+		roots = append(roots, pkg)
+	}
+	if len(variants) == 0 {
+		deps := make([]Dep, 0, len(roots))
+		symbols := newSymbolCache()
+		for _, root := range roots {
+			deps = append(deps, pkgDep{pkg: root, symbols: symbols})
+		}
+		return deps
+	}
+	t := &tests{nonTest: make(map[string]bool)}
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		t.nonTest[pkg.PkgPath] = true
+	})
+	symbols := newSymbolCache()
+	deps := make([]Dep, 0, len(roots))
+	for _, root := range roots {
+		deps = append(deps, pkgDep{pkg: root, tests: t, symbols: symbols, variants: variants[root.ID]})
+	}
+	return deps
+}
+
+// tests holds the packages needed without tests
+type tests struct {
+	nonTest map[string]bool
+}
+
+// TestDep is a Dep loaded with tests, TestOnly reports whether it is only
+// needed by tests, TestImport whether it is only imported by the test
+// files of its importer
+type TestDep interface {
+	Dep
+	TestOnly() bool
+	TestImport() bool
+}
+
+type pkgDep struct {
+	pkg        *packages.Package
+	tests      *tests
+	testImport bool
+	// symbols of pkg used by its importer, if loaded with types
+	used    []string
+	symbols *symbolCache
+	// test variants merged into a root
+	variants []*packages.Package
+}
+
+func (d pkgDep) Name() string {
+	return d.pkg.PkgPath
+}
+
+func (d pkgDep) Deps() []Dep {
+	if len(d.variants) == 0 {
+		imports := d.pkg.Imports
+		used := d.symbols.get(d.pkg)
+		deps := make([]Dep, 0, len(imports))
+		for _, i := range imports {
+			deps = append(deps, pkgDep{pkg: i, tests: d.tests, used: used[i.PkgPath], symbols: d.symbols})
+		}
+		return deps
+	}
+	// merge imports of the test variants, the internal one includes
+	// the imports of the package itself
+	imports := make(map[string]*packages.Package)
+	for _, v := range d.variants {
+		for _, i := range v.Imports {
+			if i.PkgPath != d.pkg.PkgPath {
+				imports[i.PkgPath] = i
+			}
+		}
+	}
+	nonTest := make(map[string]bool)
+	for _, i := range d.pkg.Imports {
+		nonTest[i.PkgPath] = true
+		if _, ok := imports[i.PkgPath]; !ok {
+			imports[i.PkgPath] = i
+		}
+	}
+	used := d.symbols.get(d.pkg, d.variants...)
+	deps := make([]Dep, 0, len(imports))
+	for path, i := range imports {
+		deps = append(deps, pkgDep{pkg: i, tests: d.tests, testImport: !nonTest[path], used: used[path], symbols: d.symbols})
+	}
+	return deps
+}
+
+func (d pkgDep) Symbols() []string {
+	return d.used
+}
+
+func (d pkgDep) Module() *Module {
+	return newModule(d.pkg.Module)
+}
+
+func (d pkgDep) Errors() []error {
+	var errs []error
+	for _, pkg := range append([]*packages.Package{d.pkg}, d.variants...) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// a package is std if it has no module and its files are in GOROOT,
+// packages without files are looked up by name
+func (d pkgDep) Std() bool {
+	if d.pkg.Module != nil {
+		return false
+	}
+	if len(d.pkg.GoFiles) == 0 || installedGOROOT() == "" {
+		return isStdName(d.pkg.PkgPath)
+	}
+	return inGOROOT(d.pkg.GoFiles[0])
+}
+
+func (d pkgDep) TestOnly() bool {
+	return d.tests != nil && !d.tests.nonTest[d.pkg.PkgPath]
+}
+
+func (d pkgDep) TestImport() bool {
+	return d.testImport
+}
+
+func newModule(m *packages.Module) *Module {
+	if m == nil {
+		return nil
+	}
+	return &Module{
+		Path:     m.Path,
+		Version:  m.Version,
+		Replace:  newModule(m.Replace),
+		Indirect: m.Indirect,
 	}
-	packages.PrintErrors(root)
-	return root[0], nil
 }
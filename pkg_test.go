@@ -0,0 +1,145 @@
+package gotree_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func newPackage(id, path string, imports ...*packages.Package) *packages.Package {
+	pkg := &packages.Package{ID: id, PkgPath: path, Imports: make(map[string]*packages.Package)}
+	for _, i := range imports {
+		pkg.Imports[i.PkgPath] = i
+	}
+	return pkg
+}
+
+func TestNewPackageDeps_Tests(t *testing.T) {
+	fmtPkg := newPackage("fmt", "fmt")
+	assertPkg := newPackage("assert", "assert", fmtPkg)
+	requirePkg := newPackage("require", "require", assertPkg)
+	p := newPackage("p", "p", fmtPkg)
+	// the internal test imports assert, the external one imports require
+	pTest := newPackage("p [p.test]", "p", fmtPkg, assertPkg)
+	pxTest := newPackage("p_test [p.test]", "p_test", pTest, requirePkg)
+	testMain := newPackage("p.test", "p.test", pTest, pxTest)
+	testMain.Name = "main"
+
+	deps := gotree.NewPackageDeps([]*packages.Package{p, pTest, pxTest, testMain})
+	require.Len(t, deps, 1)
+	tree, err := gotree.Tree(deps[0], gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+├── assert [test]
+│   └── fmt
+├── fmt
+└── require [test]
+    └── assert [test]
+        └── fmt
+`, "\n"+tree)
+}
+
+func TestPackageDep_Std(t *testing.T) {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	require.NoError(t, err)
+	goroot := strings.TrimSpace(string(out))
+	slog := newPackage("log/slog", "log/slog")
+	slog.GoFiles = []string{filepath.Join(goroot, "src", "log", "slog", "logger.go")}
+	// a GOPATH package shadowing a std one
+	gopathFmt := newPackage("fmt", "fmt")
+	gopathFmt.GoFiles = []string{filepath.Join("gopath", "src", "fmt", "print.go")}
+	moduleSlog := newPackage("example.com/slog", "example.com/slog")
+	moduleSlog.GoFiles = []string{filepath.Join(goroot, "src", "example.com", "slog", "slog.go")}
+	moduleSlog.Module = &packages.Module{Path: "example.com/slog"}
+	// looked up by name
+	embed := newPackage("embed", "embed")
+	p := newPackage("p", "p", slog, gopathFmt, moduleSlog, embed)
+
+	tree, err := gotree.Tree(gotree.NewPackageDep(p), gotree.WithNoStd(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+├── example.com/slog
+└── fmt
+`, "\n"+tree)
+	// deps which do not know whether they are std
+	tree, err = gotree.Tree(&mockDep{name: "p", deps: []gotree.Dep{
+		&mockDep{name: "slices"},
+		&mockDep{name: "example.com/slices"},
+	}}, gotree.WithNoStd(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+└── example.com/slices
+`, "\n"+tree)
+}
+
+func TestLoader_Env(t *testing.T) {
+	require.Nil(t, gotree.LoaderEnv(gotree.Loader{}))
+	yes, no := true, false
+	for _, c := range []struct {
+		cgo  *bool
+		last string
+	}{
+		{nil, "GOARCH=arm64"},
+		{&yes, "CGO_ENABLED=1"},
+		{&no, "CGO_ENABLED=0"},
+	} {
+		env := gotree.LoaderEnv(gotree.Loader{GOOS: "linux", GOARCH: "arm64", Cgo: c.cgo})
+		require.Equal(t, c.last, env[len(env)-1])
+	}
+	// the loader overrides the current environment
+	t.Setenv("GOOS", "plan9")
+	cmd := exec.Command("go", "env", "GOOS")
+	cmd.Env = gotree.LoaderEnv(gotree.Loader{GOOS: "windows"})
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	require.Equal(t, "windows", strings.TrimSpace(string(out)))
+}
+
+func TestLoader_Tags(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":   "module example.com/p\n",
+		"p.go":     "package p\n",
+		"extra.go": "//go:build extra\n\npackage p\n\nimport _ \"errors\"\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0666))
+	}
+	imports := func(l gotree.Loader) []string {
+		l.Dir = dir
+		pkgs, err := l.Load()
+		require.NoError(t, err)
+		require.Len(t, pkgs, 1)
+		var paths []string
+		for path := range pkgs[0].Imports {
+			paths = append(paths, path)
+		}
+		return paths
+	}
+	require.Empty(t, imports(gotree.Loader{}))
+	require.Equal(t, []string{"errors"}, imports(gotree.Loader{Tags: []string{"extra"}}))
+}
+
+func TestLoadPackages(t *testing.T) {
+	pkgs, err := gotree.LoadPackages(".", "./internal/std")
+	require.NoError(t, err)
+	require.Len(t, pkgs, 2)
+	var paths []string
+	for _, pkg := range pkgs {
+		paths = append(paths, pkg.PkgPath)
+	}
+	require.ElementsMatch(t, []string{"github.com/MaruHyl/gotree", "github.com/MaruHyl/gotree/internal/std"}, paths)
+	// one root per package
+	require.Len(t, gotree.NewPackageDeps(pkgs), 2)
+
+	_, err = gotree.LoadPackages("./img/...")
+	require.EqualError(t, err, "no packages match: ./img/...")
+}
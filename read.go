@@ -0,0 +1,129 @@
+package gotree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Read a graph written by WriteJSON or JSONTree back into a Dep, along
+// with its report, which is nil if it was written without one.
+// Occurrences of a dep are merged by name, so a graph written in dedup
+// mode gives back the same Dep as a fully expanded one. Module, test,
+// platform, symbol, error and change annotations are kept when they were
+// written.
+func ReadJSON(r io.Reader) (Dep, *Summary, error) {
+	var values []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, nil, fmt.Errorf("read json error: %v", err)
+	}
+	if len(values) == 0 || len(values) > 2 {
+		return nil, nil, fmt.Errorf("read json error: expect a root and an optional report, got %d values", len(values))
+	}
+	root := new(Node)
+	if err := json.Unmarshal(values[0], root); err != nil {
+		return nil, nil, fmt.Errorf("read json error: %v", err)
+	}
+	if root.Type != Root || root.Name == "" {
+		return nil, nil, errors.New("read json error: the first value is not a root dep")
+	}
+	var s *Summary
+	if len(values) == 2 {
+		s = new(Summary)
+		if err := json.Unmarshal(values[1], s); err != nil {
+			return nil, nil, fmt.Errorf("read json error: %v", err)
+		}
+		if s.Type != Report {
+			return nil, nil, errors.New("read json error: the second value is not a report")
+		}
+	}
+	g := &jsonGraph{
+		nodes: make(map[string]*Node),
+		edges: make(map[string][]*Node),
+	}
+	g.add(root)
+	return &jsonDep{g: g, nd: root}, s, nil
+}
+
+// jsonGraph indexes the nodes read from json by name
+type jsonGraph struct {
+	// first occurrence of a dep
+	nodes map[string]*Node
+	// deps of a dep, distinct by name
+	edges map[string][]*Node
+}
+
+func (g *jsonGraph) add(root *Node) {
+	seen := make(map[[2]string]bool)
+	Walk(root, func(nd *Node, path []*Node) bool {
+		if _, ok := g.nodes[nd.Name]; !ok {
+			g.nodes[nd.Name] = nd
+		}
+		if len(path) > 0 {
+			parent := path[len(path)-1].Name
+			edge := [2]string{parent, nd.Name}
+			if !seen[edge] {
+				seen[edge] = true
+				g.edges[parent] = append(g.edges[parent], nd)
+			}
+		}
+		return true
+	}, nil)
+}
+
+// jsonDep is a dep read from json, nd is the occurrence it was reached by,
+// which holds the annotations of the edge from its parent
+type jsonDep struct {
+	g  *jsonGraph
+	nd *Node
+}
+
+func (d *jsonDep) Name() string {
+	return d.nd.Name
+}
+
+func (d *jsonDep) Deps() []Dep {
+	edges := d.g.edges[d.nd.Name]
+	deps := make([]Dep, 0, len(edges))
+	for _, nd := range edges {
+		deps = append(deps, &jsonDep{g: d.g, nd: nd})
+	}
+	return deps
+}
+
+func (d *jsonDep) Module() *Module {
+	return d.g.nodes[d.nd.Name].Module
+}
+
+func (d *jsonDep) TestOnly() bool {
+	return d.g.nodes[d.nd.Name].Test
+}
+
+func (d *jsonDep) TestImport() bool {
+	return d.nd.TestImport
+}
+
+func (d *jsonDep) Platforms() []string {
+	return d.nd.Platforms
+}
+
+func (d *jsonDep) Symbols() []string {
+	return d.nd.Symbols
+}
+
+func (d *jsonDep) Change() Change {
+	return d.g.nodes[d.nd.Name].Change
+}
+
+func (d *jsonDep) OldModule() *Module {
+	return d.g.nodes[d.nd.Name].OldModule
+}
+
+func (d *jsonDep) Errors() []error {
+	var errs []error
+	for _, err := range d.g.nodes[d.nd.Name].Errors {
+		errs = append(errs, errors.New(err))
+	}
+	return errs
+}
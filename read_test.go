@@ -0,0 +1,82 @@
+package gotree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+// write d as json and read it back
+func readBack(t *testing.T, d gotree.Dep, options ...gotree.Option) (gotree.Dep, *gotree.Summary) {
+	json, err := gotree.JSONTree(d, options...)
+	require.NoError(t, err)
+	_dep, s, err := gotree.ReadJSON(strings.NewReader(json))
+	require.NoError(t, err)
+	return _dep, s
+}
+
+func TestReadJSON(t *testing.T) {
+	for name, d := range map[string]gotree.Dep{
+		"complete": getCompleteDep(),
+		"diamond":  getDiamondDep(),
+		"cycle":    getCycleDep(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, dedup := range []bool{false, true} {
+				read, s := readBack(t, d, gotree.WithDedup(dedup))
+				expected, err := gotree.JSONTree(d)
+				require.NoError(t, err)
+				json, err := gotree.JSONTree(read)
+				require.NoError(t, err)
+				require.Equal(t, expected, json)
+				g, err := gotree.Build(d, gotree.WithDedup(dedup))
+				require.NoError(t, err)
+				require.Equal(t, g.Summary, *s)
+			}
+		})
+	}
+}
+
+func TestReadJSON_Options(t *testing.T) {
+	read, _ := readBack(t, getCompleteDep(), gotree.WithNoReport(true))
+	tree, err := gotree.Tree(read, gotree.WithMaxLevel(1))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+└── f
+2 deps, 2 direct, 0 indirect (2 unique, 2 direct, 0 indirect)`, "\n"+tree)
+}
+
+func TestReadJSON_Annotations(t *testing.T) {
+	x := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	broken := &mockErrorDep{mockDep{name: "c"}, []error{errors.New("no Go files")}}
+	root := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "x/b", deps: []gotree.Dep{broken}}, x},
+	}}
+	read, s := readBack(t, root, gotree.WithVersions(true))
+	require.Equal(t, 1, s.Errors)
+	tree, err := gotree.Tree(read, gotree.WithVersions(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+└── x/b@v1.0.0
+    └── c [error: no Go files]
+2 deps, 1 direct, 1 indirect (2 unique, 1 direct, 1 indirect), 1 modules, 1 with errors`, "\n"+tree)
+}
+
+func TestReadJSON_Invalid(t *testing.T) {
+	for _, json := range []string{
+		``,
+		`{}`,
+		`[]`,
+		`[{"Type": "report"}]`,
+		`[{"Type": "root", "Name": "a"}, {"Type": "root", "Name": "b"}]`,
+	} {
+		_, _, err := gotree.ReadJSON(strings.NewReader(json))
+		require.Error(t, err, json)
+	}
+}
@@ -0,0 +1,253 @@
+package gotree
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Build the main package matching the given patterns(the package in the
+// current directory if no pattern is given) into the binary output, in
+// the build context of the loader
+func (l Loader) Build(output string, patterns ...string) error {
+	args := []string{"build", "-o", output}
+	if len(l.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(l.Tags, ","))
+	}
+	cmd := exec.Command("go", append(args, patterns...)...)
+	cmd.Env = l.env()
+	cmd.Dir = l.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("build error: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Get the size of every package in the binary, see ReadSizes
+func BinarySizes(binary string) (map[string]int64, error) {
+	cmd := exec.Command("go", "tool", "nm", "-size", binary)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("nm error: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ReadSizes(&stdout)
+}
+
+// Read the output of go tool nm -size and sum the size of symbols by
+// package. Uninitialized data takes no room in the binary and is not
+// counted, nor are symbols of no package(e.g. go:func.* or C symbols).
+func ReadSizes(r io.Reader) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for line := 1; s.Scan(); line++ {
+		// address size type name, the address of undefined symbols is empty
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 4 {
+			if len(fields) == 3 && fields[1] == "U" {
+				continue
+			}
+			return nil, fmt.Errorf("read sizes error: line %d: expect address, size, type and name", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("read sizes error: line %d: %v", line, err)
+		}
+		switch fields[2] {
+		case "T", "t", "R", "r", "D", "d":
+		default:
+			continue
+		}
+		if pkg := symbolPackage(strings.Join(fields[3:], " ")); pkg != "" {
+			sizes[pkg] += size
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("read sizes error: %v", err)
+	}
+	return sizes, nil
+}
+
+// import path of the package defining a symbol, empty if it has none
+func symbolPackage(name string) string {
+	name = strings.TrimPrefix(name, "type:")
+	name = strings.TrimLeft(name, "*")
+	// linker generated symbols, e.g. go:func.* or $f64.3fe62e42fee00000
+	if strings.HasPrefix(name, "go:") || strings.HasPrefix(name, "$") {
+		return ""
+	}
+	// type arguments of instances
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	// dots of the last path element are escaped as %2e
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot <= 0 {
+		return ""
+	}
+	pkg := name[:slash+1+dot]
+	if unescaped, err := url.PathUnescape(pkg); err == nil {
+		pkg = unescaped
+	}
+	return pkg
+}
+
+// Sum the size of packages by module of the deps of d, as named by
+// Modules. Deps without module(e.g. std packages) are not counted,
+// unless d itself has none.
+func ModuleSizes(d Dep, sizes map[string]int64) map[string]int64 {
+	if d == nil {
+		return nil
+	}
+	g := newGraph(d)
+	moduleSizes := make(map[string]int64)
+	for name, size := range packageSizes(g.root, sizes) {
+		_dep, ok := g.deps[name]
+		if !ok {
+			continue
+		}
+		if m := moduleOf(_dep); m != nil {
+			moduleSizes[m.Path] += size
+		} else if name == g.root {
+			moduleSizes[name] += size
+		}
+	}
+	return moduleSizes
+}
+
+// sizes by dep name, the symbols of package main are those of root
+func packageSizes(root string, sizes map[string]int64) map[string]int64 {
+	size, ok := sizes["main"]
+	if !ok || root == "main" {
+		return sizes
+	}
+	_sizes := make(map[string]int64, len(sizes))
+	for name, size := range sizes {
+		_sizes[name] = size
+	}
+	delete(_sizes, "main")
+	_sizes[root] += size
+	return _sizes
+}
+
+// cumulative size of every dep of the graph from root, its own size
+// plus those of the distinct deps reachable from it. The strongly
+// connected components of the graph are found by Tarjan's algorithm in
+// reverse topological order, so the deps reachable from each of them are
+// merged from those of its successors in one pass.
+func cumSizes(ctx context.Context, root Dep, sizes map[string]int64) (map[string]int64, error) {
+	g := newGraph(root)
+	names := g.names()
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	words := (len(names) + 63) / 64
+	// dfs number of every dep from 1, 0 if not visited yet
+	order := make([]int, len(names))
+	low := make([]int, len(names))
+	onStack := make([]bool, len(names))
+	var stack []int
+	// component of every dep, and the deps reachable from each component
+	comp := make([]int, len(names))
+	var reach [][]uint64
+	var err error
+	counter := 0
+	var strong func(v int)
+	strong = func(v int) {
+		counter++
+		order[v], low[v] = counter, counter
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, name := range g.edges[names[v]] {
+			w := index[name]
+			if order[w] == 0 {
+				strong(w)
+				if err != nil {
+					return
+				}
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], order[w])
+			}
+		}
+		if low[v] != order[v] {
+			return
+		}
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		c := len(reach)
+		set := make([]uint64, words)
+		var members []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			comp[w] = c
+			set[w/64] |= 1 << (w % 64)
+			members = append(members, w)
+			if w == v {
+				break
+			}
+		}
+		// successors out of the component are in components already done
+		for _, m := range members {
+			for _, name := range g.edges[names[m]] {
+				if k := comp[index[name]]; k != c {
+					for i, word := range reach[k] {
+						set[i] |= word
+					}
+				}
+			}
+		}
+		reach = append(reach, set)
+	}
+	strong(index[g.root])
+	if err != nil {
+		return nil, err
+	}
+	// the sum of each component is shared by its deps
+	sums := make([]int64, len(reach))
+	for c, set := range reach {
+		for i, word := range set {
+			for ; word != 0; word &= word - 1 {
+				sums[c] += sizes[names[i*64+bits.TrailingZeros64(word)]]
+			}
+		}
+	}
+	cum := make(map[string]int64, len(names))
+	for i, name := range names {
+		cum[name] = sums[comp[i]]
+	}
+	return cum, nil
+}
+
+// human readable size
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,111 @@
+package gotree_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSizes(t *testing.T) {
+	sizes, err := gotree.ReadSizes(strings.NewReader(`
+                  0 U abort
+  7467c0        234 T main.main
+  749460         51 T main.(*exitError).Error
+  b90256          1 B main.all
+  4047a0         94 T internal/abi.(*ChanType).Uncommon
+  79d7a0         16 R slices..dict.partialInsertionSortCmpFunc[*go/types.Func]
+  6866e0        168 T cmp.Or[go.shape.interface { Error() string }]
+  6b5540        517 T crypto/internal/entropy/v1%2e0%2e0.SHA384
+  b4af20        640 D crypto/internal/entropy/v1%2e0%2e0._K
+  a1c728     479904 r go:func.*
+  727b20        485 T type:.eq.SSSSM17K7M40SM12
+  79c158          8 r $f64.3fe62e42fee00000
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{
+		"main":                           285,
+		"internal/abi":                   94,
+		"slices":                         16,
+		"cmp":                            168,
+		"crypto/internal/entropy/v1.0.0": 1157,
+	}, sizes)
+	_, err = gotree.ReadSizes(strings.NewReader("7467c0 big T main.main\n"))
+	require.Error(t, err)
+}
+
+func TestSizes(t *testing.T) {
+	sizes := map[string]int64{"main": 100, "b": 2048, "c": 5000, "d": 1000, "e": 5}
+	tree, err := gotree.Tree(getDiamondDep(), gotree.WithSizes(sizes), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a (100 B, 8.0 KiB total)
+├── b (2.0 KiB, 3.0 KiB total)
+│   └── d (1000 B, 1005 B total)
+│       └── e (5 B, 5 B total)
+└── c (4.9 KiB, 5.9 KiB total)
+    ├── d (1000 B, 1005 B total)
+    │   └── e (5 B, 5 B total)
+    └── e (5 B, 5 B total)
+`, "\n"+tree)
+	tree, err = gotree.Tree(getDiamondDep(), gotree.WithSizes(sizes), gotree.WithSizeSort(true),
+		gotree.WithDedup(true), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a (100 B, 8.0 KiB total)
+├── c (4.9 KiB, 5.9 KiB total)
+│   ├── d (1000 B, 1005 B total)
+│   │   └── e (5 B, 5 B total)
+│   └── e (5 B, 5 B total)
+└── b (2.0 KiB, 3.0 KiB total)
+    └── d (1000 B, 1005 B total) (*)
+`, "\n"+tree)
+	json, err := gotree.JSONTree(getDiamondDep(), gotree.WithSizes(sizes), gotree.WithMaxLevel(1), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Contains(t, json, `
+  "Size": 100,
+  "CumSize": 8153,`)
+}
+
+func TestSizes_Cycle(t *testing.T) {
+	a := &mockDep{name: "a"}
+	b := &mockDep{name: "b"}
+	c := &mockDep{name: "c"}
+	d := &mockDep{name: "d"}
+	a.deps = []gotree.Dep{b, d}
+	b.deps = []gotree.Dep{c}
+	c.deps = []gotree.Dep{b, d}
+	sizes := map[string]int64{"a": 1, "b": 2, "c": 4, "d": 8}
+	tree, err := gotree.Tree(a, gotree.WithSizes(sizes), gotree.WithNoReport(true), gotree.WithDedup(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a (1 B, 15 B total)
+├── b (2 B, 14 B total)
+│   └── c (4 B, 14 B total)
+│       ├── b (2 B, 14 B total) (cycle)
+│       └── d (8 B, 8 B total)
+└── d (8 B, 8 B total)
+`, "\n"+tree)
+}
+
+func TestModuleSizes(t *testing.T) {
+	main := &gotree.Module{Path: "m"}
+	x := &gotree.Module{Path: "x"}
+	shared := &mockModuleDep{mockDep{name: "x/b"}, x}
+	root := &mockModuleDep{mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "m/a", deps: []gotree.Dep{shared}}, main},
+		&mockModuleDep{mockDep{name: "x/a", deps: []gotree.Dep{shared}}, x},
+		&mockDep{name: "fmt"},
+	}}, main}
+	sizes := gotree.ModuleSizes(root, map[string]int64{
+		"main": 1, "m/a": 2, "x/a": 4, "x/b": 8, "fmt": 16, "unknown": 32,
+	})
+	require.Equal(t, map[string]int64{"m": 3, "x": 12}, sizes)
+	tree, err := gotree.Tree(gotree.Modules(root), gotree.WithSizes(sizes), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+m (3 B, 15 B total)
+└── x (12 B, 12 B total)
+`, "\n"+tree)
+}
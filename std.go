@@ -0,0 +1,68 @@
+package gotree
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/MaruHyl/gotree/internal/std"
+)
+
+// StdDep is a Dep which knows whether it belongs to the standard library.
+// Other deps are looked up in the packages of the installed toolchain, or
+// in a generated table of the standard library if there is no toolchain.
+type StdDep interface {
+	Dep
+	Std() bool
+}
+
+func isStd(d Dep) bool {
+	if sd, ok := d.(StdDep); ok {
+		return sd.Std()
+	}
+	return isStdName(d.Name())
+}
+
+func isStdName(name string) bool {
+	if pkgs := stdPackages(); pkgs != nil {
+		return pkgs[name]
+	}
+	_, ok := std.StdLib[name]
+	return ok
+}
+
+var gorootOnce sync.Once
+var goroot string
+
+// GOROOT of the installed toolchain, empty if it can not be run
+func installedGOROOT() string {
+	gorootOnce.Do(func() {
+		if out, err := exec.Command("go", "env", "GOROOT").Output(); err == nil {
+			goroot = strings.TrimSpace(string(out))
+		}
+	})
+	return goroot
+}
+
+var stdOnce sync.Once
+var stdPkgs map[string]bool
+
+// std packages of the installed toolchain, nil if it can not be run
+func stdPackages() map[string]bool {
+	stdOnce.Do(func() {
+		if out, err := exec.Command("go", "list", "std").Output(); err == nil {
+			stdPkgs = make(map[string]bool)
+			for _, name := range strings.Fields(string(out)) {
+				stdPkgs[name] = true
+			}
+		}
+	})
+	return stdPkgs
+}
+
+// whether file is in the source tree of the installed toolchain
+func inGOROOT(file string) bool {
+	rel, err := filepath.Rel(filepath.Join(installedGOROOT(), "src"), file)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
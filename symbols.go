@@ -0,0 +1,91 @@
+package gotree
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/MaruHyl/gotree/internal/std"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SymbolDep is a Dep which knows the exported symbols its importer uses,
+// Symbols returns nil if they are unknown. Symbols of std packages which
+// are not in the std API table are flagged with a trailing ?
+type SymbolDep interface {
+	Dep
+	Symbols() []string
+}
+
+// sorted exported package level symbols used by pkgs, loaded with
+// Loader.Types, by the path of their package. Symbols of std packages
+// which are not in the std table are flagged with a trailing ?
+func usedSymbols(pkgs ...*packages.Package) map[string][]string {
+	used := make(map[string]map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, obj := range pkg.TypesInfo.Uses {
+			if obj == nil || obj.Pkg() == nil || !obj.Exported() || obj.Parent() != obj.Pkg().Scope() {
+				continue
+			}
+			path := obj.Pkg().Path()
+			if path == pkg.PkgPath {
+				continue
+			}
+			name := obj.Name()
+			if syms, ok := std.StdLib[path]; ok {
+				if _, ok := syms[name]; !ok {
+					name += "?"
+				}
+			}
+			if used[path] == nil {
+				used[path] = make(map[string]bool)
+			}
+			used[path][name] = true
+		}
+	}
+	symbols := make(map[string][]string, len(used))
+	for path, names := range used {
+		for name := range names {
+			symbols[path] = append(symbols[path], name)
+		}
+		sort.Strings(symbols[path])
+	}
+	return symbols
+}
+
+// symbolCache memoizes the symbols used by packages, it is shared by the
+// deps of a graph as Deps is called again for every occurrence of a dep
+type symbolCache struct {
+	mu   sync.Mutex
+	used map[symbolKey]map[string][]string
+}
+
+// a package, along with its test variants for a root loaded with tests
+type symbolKey struct {
+	pkg      *packages.Package
+	variants bool
+}
+
+func newSymbolCache() *symbolCache {
+	return &symbolCache{used: make(map[symbolKey]map[string][]string)}
+}
+
+// symbols used by pkg and its test variants, see usedSymbols
+func (c *symbolCache) get(pkg *packages.Package, variants ...*packages.Package) map[string][]string {
+	pkgs := append([]*packages.Package{pkg}, variants...)
+	if c == nil {
+		return usedSymbols(pkgs...)
+	}
+	key := symbolKey{pkg: pkg, variants: len(variants) > 0}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	used, ok := c.used[key]
+	if !ok {
+		used = usedSymbols(pkgs...)
+		c.used[key] = used
+	}
+	return used
+}
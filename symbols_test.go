@@ -0,0 +1,81 @@
+package gotree_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbols(t *testing.T) {
+	q := newTypedPackage(t, "q", `package q
+
+import "strings"
+
+func Upper(s string) string { return strings.ToUpper(s) }
+
+func Lower(s string) string { return strings.ToLower(s) }
+`, newPackage("strings", "strings"))
+	p := newTypedPackage(t, "p", `package p
+
+import (
+	"fmt"
+	"q"
+)
+
+type printer struct{}
+
+func (printer) Print(s string) { fmt.Println(q.Upper(s), fmt.Sprint(s)) }
+`, newPackage("fmt", "fmt"), q)
+
+	tree, err := gotree.Tree(gotree.NewPackageDep(p), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+├── fmt {Println, Sprint}
+└── q {Upper}
+    └── strings {ToLower, ToUpper}
+`, "\n"+tree)
+	// symbols are written in json and read back
+	json, err := gotree.JSONTree(gotree.NewPackageDep(p), gotree.WithMaxLevel(1), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Contains(t, json, `
+    "Symbols": [
+     "Println",
+     "Sprint"
+    ]`)
+	read, _, err := gotree.ReadJSON(strings.NewReader(json))
+	require.NoError(t, err)
+	tree, err = gotree.Tree(read, gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+├── fmt {Println, Sprint}
+└── q {Upper}
+`, "\n"+tree)
+}
+
+func TestSymbols_NotInTable(t *testing.T) {
+	// a std package shadowed by one which has symbols unknown to the table
+	errs := newTypedPackage(t, "errors", `package errors
+
+func New(text string) error { return nil }
+
+func Bogus() {}
+`)
+	p := newTypedPackage(t, "p", `package p
+
+import "errors"
+
+var err = errors.New("")
+
+func init() { errors.Bogus() }
+`, errs)
+	tree, err := gotree.Tree(gotree.NewPackageDep(p), gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+p
+└── errors {Bogus?, New}
+`, "\n"+tree)
+}
@@ -1,12 +1,15 @@
 package gotree
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
-	"github.com/MaruHyl/gotree/internal/std"
 	"github.com/fatih/color"
 )
 
@@ -24,75 +27,272 @@ type Dep interface {
 	Deps() []Dep
 }
 
-type dep struct {
-	Type    Type
-	Name    string
-	Matched bool
-	Deps    []dep `json:",omitempty"`
+// ErrorDep is a Dep which may have failed to load
+type ErrorDep interface {
+	Dep
+	Errors() []error
 }
 
-type report struct {
-	Type     Type
-	Deps     int
-	Direct   int
-	Indirect int
+// Get the errors of every dep reachable from d, by dep name
+func DepErrors(d Dep) map[string][]error {
+	errs := make(map[string][]error)
+	if d == nil {
+		return errs
+	}
+	for name, _dep := range newGraph(d).deps {
+		if ed, ok := _dep.(ErrorDep); ok && len(ed.Errors()) > 0 {
+			errs[name] = ed.Errors()
+		}
+	}
+	return errs
+}
+
+// Module which a dep belongs to, Indirect reports whether it is
+// only an indirect requirement of the main module
+type Module struct {
+	Path     string
+	Version  string  `json:",omitempty"`
+	Replace  *Module `json:",omitempty"`
+	Indirect bool    `json:",omitempty"`
+}
+
+// version annotation of a module, e.g. @v1.0.0 => ../x // indirect
+func (m *Module) suffix() string {
+	s := ""
+	if m.Version != "" {
+		s += "@" + m.Version
+	}
+	if m.Replace != nil {
+		s += " => " + m.Replace.Path
+		if m.Replace.Version != "" {
+			s += "@" + m.Replace.Version
+		}
+	}
+	if m.Indirect {
+		s += " // indirect"
+	}
+	return s
+}
+
+// ModuleDep is a Dep which knows its module, Module returns nil
+// if the dep does not belong to any module(e.g. std packages)
+type ModuleDep interface {
+	Dep
+	Module() *Module
+}
+
+// Node is a dep kept in the tree built from a Dep, its Deps are the
+// kept deps it imports. Level is the depth of the node, 0 for the root.
+// Deps must stay the last field written, as WriteJSON writes it after the others
+type Node struct {
+	Type       Type
+	Name       string
+	Matched    bool
+	Level      int      `json:"-"`
+	Dup        bool     `json:",omitempty"`
+	Module     *Module  `json:",omitempty"`
+	Test       bool     `json:",omitempty"` // only needed by tests
+	TestImport bool     `json:",omitempty"` // only imported by test files of the parent
+	Platforms  []string `json:",omitempty"`
+	Symbols    []string `json:",omitempty"` // used by the parent
+	Errors     []string `json:",omitempty"`
+	Cycle      bool     `json:",omitempty"` // imports a dep on its own path
+	Change     Change   `json:",omitempty"`
+	OldModule  *Module  `json:",omitempty"`
+	Size       int64    `json:",omitempty"` // in the binary, see WithSizes
+	CumSize    int64    `json:",omitempty"` // with the deps reachable from it
+	Deps       []*Node  `json:",omitempty"`
+	DepModule  *Module  `json:"-"` // Module, even if versions are not shown
+}
+
+// Summary is the report at the end of a tree.
+// Deps/Direct/Indirect count every occurrence in the tree, while
+// Unique/UniqueDirect/UniqueIndirect count distinct deps, a dep which is
+// both direct and indirect is only counted as direct.
+// Modules is the number of distinct modules besides the root's one,
+// it is only known for ModuleDep.
+// Errors is the number of distinct deps which failed to load.
+// Cycles are the distinct import cycles, each starting from its
+// smallest dep and ending with it again.
+// Added/Removed/Changed count distinct deps by change, for a Diff.
+type Summary struct {
+	Type           Type
+	Deps           int
+	Direct         int
+	Indirect       int
+	Unique         int
+	UniqueDirect   int
+	UniqueIndirect int
+	Modules        int        `json:",omitempty"`
+	Errors         int        `json:",omitempty"`
+	Cycles         [][]string `json:",omitempty"`
+	Added          int        `json:",omitempty"`
+	Removed        int        `json:",omitempty"`
+	Changed        int        `json:",omitempty"`
 }
 
 // Get dep graph(json)
 func JSONTree(d Dep, options ...Option) (string, error) {
+	return JSONTreeContext(context.Background(), d, options...)
+}
+
+// Get dep graph(json), the traversal is aborted when ctx is done
+func JSONTreeContext(ctx context.Context, d Dep, options ...Option) (string, error) {
+	sb := new(strings.Builder)
+	if err := WriteJSONContext(ctx, sb, d, options...); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Write dep graph(json) to w as the traversal proceeds
+func WriteJSON(w io.Writer, d Dep, options ...Option) error {
+	return WriteJSONContext(context.Background(), w, d, options...)
+}
+
+// Write dep graph(json) to w as the traversal proceeds,
+// the traversal is aborted when ctx is done
+func WriteJSONContext(ctx context.Context, w io.Writer, d Dep, options ...Option) error {
 	if d == nil {
-		return "", nil
+		return nil
 	}
 	opts, err := buildOpts(options...)
 	if err != nil {
-		return "", err
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	jw := &jsonWriter{w: bw}
+	bw.WriteString("[\n")
+	r, err := newWalker(ctx, opts).walk(d, jw.enter, jw.leave)
+	if err != nil {
+		return err
+	}
+	if jw.err != nil {
+		return jw.err
 	}
-	dep, r := visit(d, opts)
-	val := []interface{}{dep}
 	if !opts.noReport {
-		val = append(val, r)
+		b, err := json.MarshalIndent(r, " ", " ")
+		if err != nil {
+			return err
+		}
+		bw.WriteString(",\n ")
+		bw.Write(b)
+	}
+	bw.WriteString("\n]")
+	return bw.Flush()
+}
+
+// jsonWriter writes deps in the layout of json.MarshalIndent(v, "", " "),
+// the deps of a dep are written after its other fields
+type jsonWriter struct {
+	w   *bufio.Writer
+	err error
+	// number of deps written for each dep on the current path
+	written []int
+}
+
+func (jw *jsonWriter) indent() string {
+	return strings.Repeat(" ", 2*len(jw.written)+1)
+}
+
+func (jw *jsonWriter) enter(nd *Node, last []bool) {
+	if jw.err != nil {
+		return
 	}
-	b, err := json.MarshalIndent(val, "", " ")
+	if n := len(jw.written); n > 0 {
+		if jw.written[n-1] == 0 {
+			jw.w.WriteString(",\n" + jw.indent()[1:] + "\"Deps\": [\n")
+		} else {
+			jw.w.WriteString(",\n")
+		}
+		jw.written[n-1]++
+	}
+	indent := jw.indent()
+	b, err := json.MarshalIndent(nd, indent, " ")
 	if err != nil {
-		return "", err
+		jw.err = err
+		return
 	}
-	return string(b), nil
+	// leave the object open for its deps
+	b = bytes.TrimSuffix(b, []byte("\n"+indent+"}"))
+	jw.w.WriteString(indent)
+	jw.w.Write(b)
+	jw.written = append(jw.written, 0)
+}
+
+func (jw *jsonWriter) leave(nd *Node) {
+	if jw.err != nil {
+		return
+	}
+	n := len(jw.written)
+	indent := jw.indent()[2:]
+	if jw.written[n-1] > 0 {
+		jw.w.WriteString("\n" + indent + " ]")
+	}
+	jw.w.WriteString("\n" + indent + "}")
+	jw.written = jw.written[:n-1]
 }
 
 const prefixClose = "└── "
 const prefixOpen = "├── "
-const close = "    "
-const open = "│   "
+const closeIndent = "    "
+const openIndent = "│   "
+const dupSuffix = " (*)"
+const cycleSuffix = " (cycle)"
+const testSuffix = " [test]"
+const testImportSuffix = " [test import]"
 
 // Get dep graph(tree)
 func Tree(d Dep, options ...Option) (string, error) {
+	return TreeContext(context.Background(), d, options...)
+}
+
+// Get dep graph(tree), the traversal is aborted when ctx is done
+func TreeContext(ctx context.Context, d Dep, options ...Option) (string, error) {
+	sb := new(strings.Builder)
+	if err := WriteTreeContext(ctx, sb, d, options...); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Write dep graph(tree) to w as the traversal proceeds
+func WriteTree(w io.Writer, d Dep, options ...Option) error {
+	return WriteTreeContext(context.Background(), w, d, options...)
+}
+
+// Write dep graph(tree) to w as the traversal proceeds,
+// the traversal is aborted when ctx is done
+func WriteTreeContext(ctx context.Context, w io.Writer, d Dep, options ...Option) error {
 	if d == nil {
-		return "", nil
+		return nil
 	}
 	opts, err := buildOpts(options...)
 	if err != nil {
-		return "", err
+		return err
 	}
-	nd, r := visit(d, opts)
-	//
-	sb := new(strings.Builder)
-	var dfs func(d dep, flags []bool)
-	dfs = func(nd dep, flags []bool) {
+	bw := bufio.NewWriter(w)
+	// only platform specific deps are annotated
+	allPlatforms := -1
+	enter := func(nd *Node, last []bool) {
+		if allPlatforms < 0 {
+			allPlatforms = len(nd.Platforms)
+		}
 		// build prefix
 		prefix := ""
-		for i, flag := range flags {
-			isPrefix := i == len(flags)-1
-			if flag {
+		for i, l := range last {
+			isPrefix := i == len(last)-1
+			if !l {
 				if isPrefix {
 					prefix += prefixOpen
 				} else {
-					prefix += open
+					prefix += openIndent
 				}
 			} else {
 				if isPrefix {
 					prefix += prefixClose
 				} else {
-					prefix += close
+					prefix += closeIndent
 				}
 			}
 		}
@@ -100,95 +300,454 @@ func Tree(d Dep, options ...Option) (string, error) {
 		if nd.Matched {
 			name = color.RedString(name)
 		}
-		sb.WriteString(prefix + name + "\n")
-		// traversal children
-		for i, d := range nd.Deps {
-			isOpen := true
-			if i == len(nd.Deps)-1 {
-				isOpen = false
+		name = nd.Change.marker() + name
+		if nd.Module != nil {
+			name += nd.Module.suffix()
+		}
+		if nd.OldModule != nil {
+			if was := strings.TrimSpace(strings.TrimPrefix(nd.OldModule.suffix(), "@")); was != "" {
+				name += " (was " + was + ")"
 			}
-			flags = append(flags, isOpen)
-			dfs(d, flags)
-			flags = flags[:len(flags)-1]
 		}
+		if nd.Test {
+			name += testSuffix
+		} else if nd.TestImport {
+			name += testImportSuffix
+		}
+		if len(nd.Platforms) < allPlatforms {
+			name += " [" + strings.Join(nd.Platforms, ", ") + "]"
+		}
+		if len(nd.Symbols) > 0 {
+			name += " {" + strings.Join(nd.Symbols, ", ") + "}"
+		}
+		if opts.sizes != nil {
+			name += " (" + formatSize(nd.Size) + ", " + formatSize(nd.CumSize) + " total)"
+		}
+		if len(nd.Errors) > 0 {
+			name += color.YellowString(" [error: " + oneLine(strings.Join(nd.Errors, "; ")) + "]")
+		}
+		if nd.Dup {
+			name += dupSuffix
+		}
+		if nd.Cycle {
+			name += cycleSuffix
+		}
+		bw.WriteString(prefix + name + "\n")
+	}
+	r, err := newWalker(ctx, opts).walk(d, enter, func(*Node) {})
+	if err != nil {
+		return err
 	}
-	dfs(nd, nil)
 	if !opts.noReport {
 		fmt.Fprintf(
-			sb, "%d deps, %d direct, %d indirect",
-			r.Deps, r.Direct, r.Indirect)
+			bw, "%d deps, %d direct, %d indirect (%d unique, %d direct, %d indirect)",
+			r.Deps, r.Direct, r.Indirect, r.Unique, r.UniqueDirect, r.UniqueIndirect)
+		if r.Modules > 0 {
+			fmt.Fprintf(bw, ", %d modules", r.Modules)
+		}
+		if r.Errors > 0 {
+			fmt.Fprintf(bw, ", %d with errors", r.Errors)
+		}
+		if len(r.Cycles) > 0 {
+			fmt.Fprintf(bw, ", %d cycles", len(r.Cycles))
+		}
+		if r.Added > 0 {
+			fmt.Fprintf(bw, ", %d added", r.Added)
+		}
+		if r.Removed > 0 {
+			fmt.Fprintf(bw, ", %d removed", r.Removed)
+		}
+		if r.Changed > 0 {
+			fmt.Fprintf(bw, ", %d changed", r.Changed)
+		}
 	}
-	return sb.String(), nil
+	return bw.Flush()
+}
+
+// s on a single line, so it does not break the lines of the tree
+func oneLine(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.Join(lines, " ")
 }
 
-// dfs traversal
-func visit(d Dep, opts options) (dep, report) {
-	var dfs func(
-		d Dep, level int) (nd dep, depsCount int, directCount int, indirectCount int, filtered bool)
-	dfs = func(
-		d Dep, level int) (nd dep, depsCount int, directCount int, indirectCount int, filtered bool) {
-		// filter out std or internal packages
-		if opts.noStd && isStd(d.Name()) {
-			filtered = true
+// dfs traversal, building the whole tree
+func visit(ctx context.Context, d Dep, opts options) (*Node, Summary, error) {
+	var root *Node
+	var stack []*Node
+	enter := func(nd *Node, last []bool) {
+		stack = append(stack, nd)
+	}
+	leave := func(nd *Node) {
+		stack = stack[:len(stack)-1]
+		if len(stack) == 0 {
+			root = nd
 			return
 		}
-		if opts.noInternal && isInternal(d.Name()) {
-			filtered = true
+		parent := stack[len(stack)-1]
+		parent.Deps = append(parent.Deps, nd)
+	}
+	r, err := newWalker(ctx, opts).walk(d, enter, leave)
+	if err != nil {
+		return nil, Summary{}, err
+	}
+	return root, r, nil
+}
+
+// walker traverses deps in dfs order. A dep is kept if it matches the
+// filter or any of its deps within max level is kept, only the first
+// occurrence of a dep is expanded in dedup mode.
+type walker struct {
+	ctx     context.Context
+	opts    options
+	fetcher *fetcher
+	// expanded deps, with the levels left below their deepest expansion
+	expanded map[string]expansion
+	// deps on the current path, which must not be expanded again
+	visiting map[string]bool
+	path     []string
+	// memo and path of keep
+	kept    map[keepKey]bool
+	keeping map[string]int
+	counter *counter
+	// sizes of deps and of the deps reachable from them
+	sizes    map[string]int64
+	cumSizes map[string]int64
+}
+
+// expansion of a dep, depth is the number of levels left below it,
+// 0 if there is no max level
+type expansion struct {
+	hasDeps bool
+	depth   int
+}
+
+type keepKey struct {
+	name  string
+	level int
+}
+
+func newWalker(ctx context.Context, opts options) *walker {
+	w := &walker{
+		ctx:      ctx,
+		opts:     opts,
+		expanded: make(map[string]expansion),
+		visiting: make(map[string]bool),
+		kept:     make(map[keepKey]bool),
+		keeping:  make(map[string]int),
+		counter:  newCounter(),
+	}
+	if opts.concurrency > 0 {
+		w.fetcher = newFetcher(ctx, opts.concurrency)
+	}
+	return w
+}
+
+// deps of d, fetched by the fetcher if any
+func (w *walker) deps(d Dep) []Dep {
+	if w.fetcher != nil {
+		return w.fetcher.deps(d)
+	}
+	return d.Deps()
+}
+
+// walk from root, calling enter before the deps of a dep and leave after
+// them. last tells whether each dep on the path is the last kept dep of
+// its parent, the root excluded. It stops with the error of ctx once done,
+// after the Deps calls already started.
+func (w *walker) walk(root Dep, enter func(nd *Node, last []bool), leave func(nd *Node)) (Summary, error) {
+	if w.fetcher != nil {
+		defer w.fetcher.wait()
+	}
+	if w.opts.sizes != nil {
+		w.sizes = packageSizes(root.Name(), w.opts.sizes)
+		cum, err := cumSizes(w.ctx, root, w.sizes)
+		if err != nil {
+			return Summary{}, err
+		}
+		w.cumSizes = cum
+	}
+	var dfs func(d Dep, level int, last []bool)
+	dfs = func(d Dep, level int, last []bool) {
+		if w.ctx.Err() != nil {
 			return
 		}
-		//
-		var t Type
-		switch level {
-		case 0:
-			t = Root
-		case 1:
-			t = Direct
-			depsCount++
-			directCount++
+		nd := w.newDep(d, level)
+		depth := 0
+		if w.opts.maxLevel > 0 {
+			depth = w.opts.maxLevel - level
+		}
+		// a dep is only a dup if it was expanded at least as deep before
+		e, seen := w.expanded[nd.Name]
+		var deps []Dep
+		expand := false
+		switch {
+		case seen && w.opts.dedup && e.depth >= depth:
+			nd.Dup = e.hasDeps
+		case w.opts.maxLevel > 0 && level >= w.opts.maxLevel:
+		case w.visiting[nd.Name]:
+			nd.Cycle = true
+			w.counter.addCycle(w.path, nd.Name)
 		default:
-			t = Indirect
-			depsCount++
-			indirectCount++
+			expand = true
+			deps = w.keptDeps(d, level)
 		}
-		nd = dep{
-			Type:    t,
-			Name:    d.Name(),
-			Matched: !opts.filter.Filter(d.Name()),
+		w.counter.add(nd)
+		enter(nd, last)
+		if expand {
+			w.visiting[nd.Name] = true
+			w.path = append(w.path, nd.Name)
+			for i, _dep := range deps {
+				dfs(_dep, level+1, append(last, i == len(deps)-1))
+			}
+			w.path = w.path[:len(w.path)-1]
+			delete(w.visiting, nd.Name)
+			w.expanded[nd.Name] = expansion{hasDeps: len(deps) > 0, depth: depth}
 		}
-		filtered = !nd.Matched
-		if opts.maxLevel > 0 && level >= opts.maxLevel {
-			return
+		leave(nd)
+	}
+	dfs(root, 0, nil)
+	if err := w.ctx.Err(); err != nil {
+		return Summary{}, err
+	}
+	return w.counter.report(), nil
+}
+
+// build a dep without its deps
+func (w *walker) newDep(d Dep, level int) *Node {
+	var t Type
+	switch level {
+	case 0:
+		t = Root
+	case 1:
+		t = Direct
+	default:
+		t = Indirect
+	}
+	nd := &Node{
+		Type:    t,
+		Name:    d.Name(),
+		Matched: !w.opts.filter.Filter(d.Name()),
+		Level:   level,
+	}
+	if md, ok := d.(ModuleDep); ok {
+		nd.DepModule = md.Module()
+		if w.opts.versions {
+			nd.Module = nd.DepModule
+		}
+	}
+	if td, ok := d.(TestDep); ok {
+		nd.Test = td.TestOnly()
+		nd.TestImport = td.TestImport()
+	}
+	if pd, ok := d.(PlatformDep); ok {
+		nd.Platforms = pd.Platforms()
+	}
+	if sd, ok := d.(SymbolDep); ok {
+		nd.Symbols = sd.Symbols()
+	}
+	if ed, ok := d.(ErrorDep); ok {
+		for _, err := range ed.Errors() {
+			nd.Errors = append(nd.Errors, err.Error())
+		}
+	}
+	if dd, ok := d.(DiffDep); ok {
+		nd.Change = dd.Change()
+		if w.opts.versions {
+			nd.OldModule = dd.OldModule()
 		}
-		_deps := d.Deps()
-		sort.Slice(_deps, func(i, j int) bool {
-			return _deps[i].Name() < _deps[j].Name()
-		})
-		for _, _dep := range _deps {
-			_nd, _depsCount, _directCount, _indirectCount, _filtered :=
-				dfs(_dep, level+1)
-			if _filtered {
-				continue
+	}
+	if w.sizes != nil {
+		nd.Size = w.sizes[nd.Name]
+		nd.CumSize = w.cumSizes[nd.Name]
+	}
+	return nd
+}
+
+// deps of d which are kept, sorted by name, or by cumulative size
+// in size sort mode
+func (w *walker) keptDeps(d Dep, level int) []Dep {
+	// copy as deps may be memoized by the fetcher
+	_deps := append([]Dep(nil), w.deps(d)...)
+	sort.Slice(_deps, func(i, j int) bool {
+		if w.opts.sizeSort && w.cumSizes != nil {
+			si, sj := w.cumSizes[_deps[i].Name()], w.cumSizes[_deps[j].Name()]
+			if si != sj {
+				return si > sj
 			}
-			filtered = false
-			depsCount += _depsCount
-			directCount += _directCount
-			indirectCount += _indirectCount
-			nd.Deps = append(nd.Deps, _nd)
 		}
-		return
+		return _deps[i].Name() < _deps[j].Name()
+	})
+	deps := _deps[:0]
+	for _, _dep := range _deps {
+		if w.keep(_dep, level+1) {
+			deps = append(deps, _dep)
+		}
+	}
+	return deps
+}
+
+// whether d is kept at level, it is memoized so the graph
+// is only traversed once more when a filter is used
+func (w *walker) keep(d Dep, level int) bool {
+	kept, _ := w.keepFrom(d, level)
+	return kept
+}
+
+// keep, along with the position in keeping of the first dep still being
+// decided on which the result depends, len(keeping) if there is none.
+// Such a result is only memoized once that dep is decided, as it may be
+// kept through another path.
+func (w *walker) keepFrom(d Dep, level int) (bool, int) {
+	name := d.Name()
+	pos := len(w.keeping)
+	// filter out std or internal packages
+	if w.opts.noStd && isStd(d) {
+		return false, pos
+	}
+	if w.opts.noInternal && isInternal(name) {
+		return false, pos
+	}
+	if !w.opts.filter.Filter(name) {
+		return true, pos
 	}
-	nd, depsCount, directCount, indirectCount, _ := dfs(d, 0)
-	return nd, report{
-		Type:     Report,
-		Deps:     depsCount,
-		Direct:   directCount,
-		Indirect: indirectCount,
+	if w.opts.maxLevel > 0 && level >= w.opts.maxLevel {
+		return false, pos
 	}
+	key := keepKey{name: name}
+	if w.opts.maxLevel > 0 {
+		key.level = level
+	}
+	if kept, ok := w.kept[key]; ok {
+		return kept, pos
+	}
+	if i, ok := w.keeping[name]; ok {
+		return false, i
+	}
+	if w.ctx.Err() != nil {
+		return false, pos
+	}
+	w.keeping[name] = pos
+	kept, low := false, pos+1
+	for _, _dep := range w.deps(d) {
+		_kept, _low := w.keepFrom(_dep, level+1)
+		if _kept {
+			// kept whatever the deps being decided are
+			kept, low = true, pos+1
+			break
+		}
+		if _low < low {
+			low = _low
+		}
+	}
+	delete(w.keeping, name)
+	if low >= pos {
+		w.kept[key] = kept
+		low = pos
+	}
+	return kept, low
+}
+
+// counter counts the deps of a traversal
+type counter struct {
+	r        Summary
+	root     *Node
+	direct   map[string]bool
+	indirect map[string]bool
+	modules  map[string]bool
+	errors   map[string]bool
+	cycles   map[string]bool
+	changes  map[string]Change
 }
 
-func isStd(name string) bool {
-	_, ok := std.StdLib[name]
-	return ok
+func newCounter() *counter {
+	return &counter{
+		r:        Summary{Type: Report},
+		direct:   make(map[string]bool),
+		indirect: make(map[string]bool),
+		modules:  make(map[string]bool),
+		errors:   make(map[string]bool),
+		cycles:   make(map[string]bool),
+		changes:  make(map[string]Change),
+	}
+}
+
+func (c *counter) add(nd *Node) {
+	if len(nd.Errors) > 0 {
+		c.errors[nd.Name] = true
+	}
+	if nd.Change != "" {
+		c.changes[nd.Name] = nd.Change
+	}
+	switch nd.Type {
+	case Root:
+		c.root = nd
+	case Direct:
+		c.r.Deps++
+		c.r.Direct++
+		c.direct[nd.Name] = true
+	case Indirect:
+		c.r.Deps++
+		c.r.Indirect++
+		c.indirect[nd.Name] = true
+	}
+	if nd.DepModule != nil {
+		c.modules[nd.DepModule.Path] = true
+	}
+}
+
+// record the cycle closed by importing name from the end of path
+func (c *counter) addCycle(path []string, name string) {
+	i := len(path) - 1
+	for path[i] != name {
+		i--
+	}
+	cycle := path[i:]
+	// rotate the cycle to start from its smallest dep
+	min := 0
+	for j := range cycle {
+		if cycle[j] < cycle[min] {
+			min = j
+		}
+	}
+	rotated := make([]string, 0, len(cycle)+1)
+	rotated = append(rotated, cycle[min:]...)
+	rotated = append(rotated, cycle[:min]...)
+	rotated = append(rotated, cycle[min])
+	key := strings.Join(rotated, " ")
+	if !c.cycles[key] {
+		c.cycles[key] = true
+		c.r.Cycles = append(c.r.Cycles, rotated)
+	}
+}
+
+func (c *counter) report() Summary {
+	r := c.r
+	r.UniqueDirect = len(c.direct)
+	for name := range c.indirect {
+		if !c.direct[name] {
+			r.UniqueIndirect++
+		}
+	}
+	r.Unique = r.UniqueDirect + r.UniqueIndirect
+	r.Modules = len(c.modules)
+	if c.root != nil && c.root.DepModule != nil && c.modules[c.root.DepModule.Path] {
+		r.Modules--
+	}
+	r.Errors = len(c.errors)
+	for _, change := range c.changes {
+		switch change {
+		case Added:
+			r.Added++
+		case Removed:
+			r.Removed++
+		case Changed:
+			r.Changed++
+		}
+	}
+	return r
 }
 
 func isInternal(name string) bool {
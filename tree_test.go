@@ -1,13 +1,25 @@
 package gotree_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/MaruHyl/gotree"
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	// outputs are compared without the escapes of colors, whatever the
+	// terminal and the order of tests
+	color.NoColor = true
+	os.Exit(m.Run())
+}
+
 type mockDep struct {
 	name string
 	deps []gotree.Dep
@@ -63,7 +75,10 @@ func TestTree_Json_Empty(t *testing.T) {
   "Type": "report",
   "Deps": 0,
   "Direct": 0,
-  "Indirect": 0
+  "Indirect": 0,
+  "Unique": 0,
+  "UniqueDirect": 0,
+  "UniqueIndirect": 0
  }
 ]`, "\n"+json)
 }
@@ -133,7 +148,10 @@ func TestTree_Json(t *testing.T) {
   "Type": "report",
   "Deps": 8,
   "Direct": 2,
-  "Indirect": 6
+  "Indirect": 6,
+  "Unique": 8,
+  "UniqueDirect": 2,
+  "UniqueIndirect": 6
  }
 ]`, "\n"+json)
 	//
@@ -181,7 +199,10 @@ func TestTree_Json(t *testing.T) {
   "Type": "report",
   "Deps": 5,
   "Direct": 2,
-  "Indirect": 3
+  "Indirect": 3,
+  "Unique": 5,
+  "UniqueDirect": 2,
+  "UniqueIndirect": 3
  }
 ]`, "\n"+json)
 }
@@ -198,7 +219,7 @@ func TestTree_Empty(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `
 root
-0 deps, 0 direct, 0 indirect`, "\n"+tree)
+0 deps, 0 direct, 0 indirect (0 unique, 0 direct, 0 indirect)`, "\n"+tree)
 }
 
 func TestTree(t *testing.T) {
@@ -213,7 +234,7 @@ a
     ├── g
     └── h
         └── i
-8 deps, 2 direct, 6 indirect`
+8 deps, 2 direct, 6 indirect (8 unique, 2 direct, 6 indirect)`
 		tree, err := gotree.Tree(getCompleteDep())
 		require.NoError(t, err)
 		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
@@ -227,7 +248,7 @@ a
 └── f
     ├── g
     └── h
-5 deps, 2 direct, 3 indirect`
+5 deps, 2 direct, 3 indirect (5 unique, 2 direct, 3 indirect)`
 		tree, err := gotree.Tree(getCompleteDep(), gotree.WithMaxLevel(2))
 		require.NoError(t, err)
 		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
@@ -270,7 +291,10 @@ func TestFilter(t *testing.T) {
   "Type": "report",
   "Deps": 2,
   "Direct": 1,
-  "Indirect": 1
+  "Indirect": 1,
+  "Unique": 2,
+  "UniqueDirect": 1,
+  "UniqueIndirect": 1
  }
 ]`, "\n"+json)
 
@@ -280,5 +304,414 @@ func TestFilter(t *testing.T) {
 a
 └── b
     └── c
-2 deps, 1 direct, 1 indirect`, "\n"+tree)
+2 deps, 1 direct, 1 indirect (2 unique, 1 direct, 1 indirect)`, "\n"+tree)
+}
+
+func TestFilter_Cycle(t *testing.T) {
+	// b is kept at root level through a, though a was being decided
+	// when b was first looked at
+	a := &mockDep{name: "a"}
+	b := &mockDep{name: "b", deps: []gotree.Dep{a}}
+	a.deps = []gotree.Dep{b, &mockDep{name: "c"}}
+	root := &mockDep{name: "root", deps: []gotree.Dep{a, b}}
+	tree, err := gotree.Tree(root, gotree.WithFilter(fixedFilter{"c"}))
+	require.NoError(t, err)
+	require.Equal(t, `
+root
+├── a
+│   ├── b
+│   │   └── a (cycle)
+│   └── c
+└── b
+    └── a
+        ├── b (cycle)
+        └── c
+8 deps, 2 direct, 6 indirect (3 unique, 2 direct, 1 indirect), 1 cycles`, "\n"+tree)
+}
+
+func getDiamondDep() gotree.Dep {
+	// init nodes
+	nodeMap := make(map[string]*mockDep)
+	nodeMap["a"] = &mockDep{name: "a"}
+	nodeMap["b"] = &mockDep{name: "b"}
+	nodeMap["c"] = &mockDep{name: "c"}
+	nodeMap["d"] = &mockDep{name: "d"}
+	nodeMap["e"] = &mockDep{name: "e"}
+	// build graph
+	nodeMap["d"].deps = []gotree.Dep{nodeMap["e"]}
+	nodeMap["b"].deps = []gotree.Dep{nodeMap["d"]}
+	nodeMap["c"].deps = []gotree.Dep{nodeMap["d"], nodeMap["e"]}
+	nodeMap["a"].deps = []gotree.Dep{nodeMap["b"], nodeMap["c"]}
+	return nodeMap["a"]
+}
+
+func TestDedup(t *testing.T) {
+	t.Run("full", func(t *testing.T) {
+		const result = `
+a
+├── b
+│   └── d
+│       └── e
+└── c
+    ├── d
+    │   └── e
+    └── e
+7 deps, 2 direct, 5 indirect (4 unique, 2 direct, 2 indirect)`
+		tree, err := gotree.Tree(getDiamondDep())
+		require.NoError(t, err)
+		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
+	})
+
+	t.Run("dedup", func(t *testing.T) {
+		const result = `
+a
+├── b
+│   └── d
+│       └── e
+└── c
+    ├── d (*)
+    └── e
+6 deps, 2 direct, 4 indirect (4 unique, 2 direct, 2 indirect)`
+		tree, err := gotree.Tree(getDiamondDep(), gotree.WithDedup(true))
+		require.NoError(t, err)
+		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
+	})
+
+	t.Run("maxLevel", func(t *testing.T) {
+		// d is not expanded under b, so it is not a dup under c
+		const result = `
+a
+├── b
+│   └── d
+└── c
+    ├── d
+    └── e
+5 deps, 2 direct, 3 indirect (4 unique, 2 direct, 2 indirect)`
+		tree, err := gotree.Tree(getDiamondDep(), gotree.WithDedup(true), gotree.WithMaxLevel(2))
+		require.NoError(t, err)
+		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
+	})
+
+	t.Run("maxLevel deeper", func(t *testing.T) {
+		// x is expanded again as a direct dep, as it was cut by the max
+		// level under b
+		x := &mockDep{name: "x", deps: []gotree.Dep{
+			&mockDep{name: "y", deps: []gotree.Dep{&mockDep{name: "z"}}},
+		}}
+		root := &mockDep{name: "a", deps: []gotree.Dep{
+			&mockDep{name: "b", deps: []gotree.Dep{&mockDep{name: "c", deps: []gotree.Dep{x}}}},
+			x,
+		}}
+		const result = `
+a
+├── b
+│   └── c
+│       └── x
+│           └── y
+└── x
+    └── y
+        └── z
+7 deps, 2 direct, 5 indirect (5 unique, 2 direct, 3 indirect)`
+		tree, err := gotree.Tree(root, gotree.WithDedup(true), gotree.WithMaxLevel(4))
+		require.NoError(t, err)
+		require.Equal(t, strings.TrimPrefix(result, "\n"), tree)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		json, err := gotree.JSONTree(getDiamondDep(),
+			gotree.WithDedup(true), gotree.WithFilter(fixedFilter{"e"}))
+		require.NoError(t, err)
+		require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "a",
+  "Matched": false,
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "b",
+    "Matched": false,
+    "Deps": [
+     {
+      "Type": "indirect",
+      "Name": "d",
+      "Matched": false,
+      "Deps": [
+       {
+        "Type": "indirect",
+        "Name": "e",
+        "Matched": true
+       }
+      ]
+     }
+    ]
+   },
+   {
+    "Type": "direct",
+    "Name": "c",
+    "Matched": false,
+    "Deps": [
+     {
+      "Type": "indirect",
+      "Name": "d",
+      "Matched": false,
+      "Dup": true
+     },
+     {
+      "Type": "indirect",
+      "Name": "e",
+      "Matched": true
+     }
+    ]
+   }
+  ]
+ },
+ {
+  "Type": "report",
+  "Deps": 6,
+  "Direct": 2,
+  "Indirect": 4,
+  "Unique": 4,
+  "UniqueDirect": 2,
+  "UniqueIndirect": 2
+ }
+]`, "\n"+json)
+	})
+}
+
+type mockModuleDep struct {
+	mockDep
+	module *gotree.Module
+}
+
+func (n *mockModuleDep) Module() *gotree.Module {
+	return n.module
+}
+
+func TestReport_Modules(t *testing.T) {
+	main := &gotree.Module{Path: "m"}
+	x := &gotree.Module{Path: "x", Version: "v1.0.0"}
+	leaf := &mockModuleDep{mockDep{name: "x/b"}, x}
+	root := &mockModuleDep{mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "m/a", deps: []gotree.Dep{leaf}}, main},
+		&mockModuleDep{mockDep{name: "x/a", deps: []gotree.Dep{leaf}}, x},
+		&mockDep{name: "fmt"},
+	}}, main}
+	tree, err := gotree.Tree(root)
+	require.NoError(t, err)
+	require.Equal(t, `
+m
+├── fmt
+├── m/a
+│   └── x/b
+└── x/a
+    └── x/b
+5 deps, 3 direct, 2 indirect (4 unique, 3 direct, 1 indirect), 1 modules`, "\n"+tree)
+}
+
+type mockErrorDep struct {
+	mockDep
+	errs []error
+}
+
+func (n *mockErrorDep) Errors() []error {
+	return n.errs
+}
+
+func TestErrors(t *testing.T) {
+	broken := &mockErrorDep{mockDep{name: "c"}, []error{errors.New("no Go files")}}
+	root := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{broken}},
+		broken,
+	}}
+	require.Equal(t, map[string][]error{"c": broken.errs}, gotree.DepErrors(root))
+
+	tree, err := gotree.Tree(root)
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+│   └── c [error: no Go files]
+└── c [error: no Go files]
+3 deps, 2 direct, 1 indirect (2 unique, 2 direct, 0 indirect), 1 with errors`, "\n"+tree)
+
+	json, err := gotree.JSONTree(root, gotree.WithMaxLevel(1))
+	require.NoError(t, err)
+	require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "a",
+  "Matched": true,
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "b",
+    "Matched": true
+   },
+   {
+    "Type": "direct",
+    "Name": "c",
+    "Matched": true,
+    "Errors": [
+     "no Go files"
+    ]
+   }
+  ]
+ },
+ {
+  "Type": "report",
+  "Deps": 2,
+  "Direct": 2,
+  "Indirect": 0,
+  "Unique": 2,
+  "UniqueDirect": 2,
+  "UniqueIndirect": 0,
+  "Errors": 1
+ }
+]`, "\n"+json)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestWrite(t *testing.T) {
+	// nested deps, deps without deps and annotations needing escapes
+	broken := &mockErrorDep{mockDep{name: "c"}, []error{errors.New("bad \"quote\" <tag> & \\\n\tline")}}
+	root := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{broken, &mockDep{name: "d"}}},
+		&mockModuleDep{mockDep{name: "e"}, &gotree.Module{Path: "x/é", Version: "v1.0.0"}},
+	}}
+	buf := new(bytes.Buffer)
+	require.NoError(t, gotree.WriteTree(buf, root, gotree.WithVersions(true)))
+	require.Equal(t, `
+a
+├── b
+│   ├── c [error: bad "quote" <tag> & \ line]
+│   └── d
+└── e@v1.0.0
+4 deps, 2 direct, 2 indirect (4 unique, 2 direct, 2 indirect), 1 modules, 1 with errors`, "\n"+buf.String())
+
+	// the json is written in the layout of json.MarshalIndent
+	for _, c := range []struct {
+		opts   []gotree.Option
+		report bool
+	}{
+		{[]gotree.Option{gotree.WithVersions(true)}, true},
+		{[]gotree.Option{gotree.WithVersions(true), gotree.WithNoReport(true)}, false},
+		{[]gotree.Option{gotree.WithMaxLevel(1)}, true},
+	} {
+		g, err := gotree.Build(root, c.opts...)
+		require.NoError(t, err)
+		values := []interface{}{g.Root}
+		if c.report {
+			values = append(values, g.Summary)
+		}
+		expected, err := json.MarshalIndent(values, "", " ")
+		require.NoError(t, err)
+		buf.Reset()
+		require.NoError(t, gotree.WriteJSON(buf, root, c.opts...))
+		require.Equal(t, string(expected), buf.String())
+	}
+
+	require.EqualError(t, gotree.WriteTree(failingWriter{}, getDiamondDep()), "write error")
+	require.EqualError(t, gotree.WriteJSON(failingWriter{}, getDiamondDep()), "write error")
+}
+
+func getCycleDep() gotree.Dep {
+	// init nodes
+	nodeMap := make(map[string]*mockDep)
+	nodeMap["a"] = &mockDep{name: "a"}
+	nodeMap["b"] = &mockDep{name: "b"}
+	nodeMap["c"] = &mockDep{name: "c"}
+	// build graph
+	nodeMap["a"].deps = []gotree.Dep{nodeMap["b"]}
+	nodeMap["b"].deps = []gotree.Dep{nodeMap["c"]}
+	nodeMap["c"].deps = []gotree.Dep{nodeMap["a"], nodeMap["b"]}
+	return nodeMap["a"]
+}
+
+func TestCycle(t *testing.T) {
+	tree, err := gotree.Tree(getCycleDep(), gotree.WithDedup(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+└── b
+    └── c
+        ├── a (cycle)
+        └── b (cycle)
+4 deps, 1 direct, 3 indirect (3 unique, 1 direct, 2 indirect), 2 cycles`, "\n"+tree)
+
+	// b is kept as it leads to a through the cycle
+	json, err := gotree.JSONTree(getCycleDep(), gotree.WithFilter(fixedFilter{"a"}))
+	require.NoError(t, err)
+	require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "a",
+  "Matched": true,
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "b",
+    "Matched": false,
+    "Deps": [
+     {
+      "Type": "indirect",
+      "Name": "c",
+      "Matched": false,
+      "Deps": [
+       {
+        "Type": "indirect",
+        "Name": "a",
+        "Matched": true,
+        "Cycle": true
+       },
+       {
+        "Type": "indirect",
+        "Name": "b",
+        "Matched": false,
+        "Cycle": true
+       }
+      ]
+     }
+    ]
+   }
+  ]
+ },
+ {
+  "Type": "report",
+  "Deps": 4,
+  "Direct": 1,
+  "Indirect": 3,
+  "Unique": 3,
+  "UniqueDirect": 1,
+  "UniqueIndirect": 2,
+  "Cycles": [
+   [
+    "a",
+    "b",
+    "c",
+    "a"
+   ],
+   [
+    "b",
+    "c",
+    "b"
+   ]
+  ]
+ }
+]`, "\n"+json)
+
+	// other traversals must terminate as well
+	require.Len(t, gotree.Why(getCycleDep(), "c", 0), 1)
+	_, err = gotree.DOT(getCycleDep())
+	require.NoError(t, err)
+	_, err = gotree.Tree(gotree.Modules(getCycleDep()))
+	require.NoError(t, err)
 }
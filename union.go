@@ -0,0 +1,85 @@
+package gotree
+
+// PlatformDep is a Dep which may only exist on some platforms,
+// Platforms returns the platforms where it is imported by its parent
+type PlatformDep interface {
+	Dep
+	Platforms() []string
+}
+
+// Merge the graphs of roots, loaded for the platforms of the same index,
+// into a PlatformDep. Roots should have the same name.
+func Union(platforms []string, roots []Dep) Dep {
+	if len(roots) == 0 {
+		return nil
+	}
+	u := &union{platforms: platforms}
+	for _, root := range roots {
+		u.graphs = append(u.graphs, newGraph(root))
+	}
+	return &unionDep{u: u, name: roots[0].Name(), platforms: platforms}
+}
+
+type union struct {
+	platforms []string
+	graphs    []*graph
+}
+
+type unionDep struct {
+	u         *union
+	name      string
+	platforms []string
+}
+
+func (d *unionDep) Name() string {
+	return d.name
+}
+
+func (d *unionDep) Deps() []Dep {
+	var names []string
+	platforms := make(map[string][]string)
+	for i, g := range d.u.graphs {
+		for _, name := range g.edges[d.name] {
+			if _, ok := platforms[name]; !ok {
+				names = append(names, name)
+			}
+			platforms[name] = append(platforms[name], d.u.platforms[i])
+		}
+	}
+	deps := make([]Dep, 0, len(names))
+	for _, name := range names {
+		deps = append(deps, &unionDep{u: d.u, name: name, platforms: platforms[name]})
+	}
+	return deps
+}
+
+func (d *unionDep) Platforms() []string {
+	return d.platforms
+}
+
+func (d *unionDep) Module() *Module {
+	for _, g := range d.u.graphs {
+		if md, ok := g.deps[d.name].(ModuleDep); ok {
+			return md.Module()
+		}
+	}
+	return nil
+}
+
+func (d *unionDep) Errors() []error {
+	for _, g := range d.u.graphs {
+		if ed, ok := g.deps[d.name].(ErrorDep); ok {
+			return ed.Errors()
+		}
+	}
+	return nil
+}
+
+func (d *unionDep) Std() bool {
+	for _, g := range d.u.graphs {
+		if _dep, ok := g.deps[d.name]; ok {
+			return isStd(_dep)
+		}
+	}
+	return isStdName(d.name)
+}
@@ -0,0 +1,68 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnion(t *testing.T) {
+	require.Nil(t, gotree.Union(nil, nil))
+
+	unix := &mockDep{name: "unix"}
+	linux := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{unix}},
+		&mockDep{name: "c", deps: []gotree.Dep{unix}},
+	}}
+	windows := &mockDep{name: "a", deps: []gotree.Dep{
+		&mockDep{name: "b", deps: []gotree.Dep{&mockDep{name: "windows"}}},
+		&mockDep{name: "c", deps: []gotree.Dep{unix}},
+	}}
+	u := gotree.Union([]string{"linux", "windows"}, []gotree.Dep{linux, windows})
+	tree, err := gotree.Tree(u, gotree.WithNoReport(true))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+│   ├── unix [linux]
+│   └── windows [windows]
+└── c
+    └── unix
+`, "\n"+tree)
+
+	json, err := gotree.JSONTree(u, gotree.WithNoReport(true), gotree.WithMaxLevel(1))
+	require.NoError(t, err)
+	require.Equal(t, `
+[
+ {
+  "Type": "root",
+  "Name": "a",
+  "Matched": true,
+  "Platforms": [
+   "linux",
+   "windows"
+  ],
+  "Deps": [
+   {
+    "Type": "direct",
+    "Name": "b",
+    "Matched": true,
+    "Platforms": [
+     "linux",
+     "windows"
+    ]
+   },
+   {
+    "Type": "direct",
+    "Name": "c",
+    "Matched": true,
+    "Platforms": [
+     "linux",
+     "windows"
+    ]
+   }
+  ]
+ }
+]`, "\n"+json)
+}
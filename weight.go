@@ -0,0 +1,118 @@
+package gotree
+
+import (
+	"context"
+	"sort"
+)
+
+// Weight of a direct dep. Exclusive counts the deps which would be removed
+// along with it, those only reachable through it, and Total every dep
+// reachable from it, itself included in both. Modules besides the root's
+// one are only known for ModuleDep, a module is exclusive if all of its
+// packages are.
+type Weight struct {
+	Name             string
+	Exclusive        int
+	Total            int
+	ExclusiveModules int
+	TotalModules     int
+}
+
+// Get the weight of every direct dep of d in the graph kept by the
+// options, heaviest first
+func Weights(d Dep, options ...Option) ([]Weight, error) {
+	if d == nil {
+		return nil, nil
+	}
+	opts, err := buildOpts(options...)
+	if err != nil {
+		return nil, err
+	}
+	// every dep appears once in dedup mode, the traversal can only fail
+	// on cancellation
+	opts.dedup = true
+	g, _ := buildGraph(context.Background(), d, opts)
+	nodes, edges := g.Nodes, g.Edges
+	root := d.Name()
+	succ := make(map[string][]string)
+	for _, e := range edges {
+		succ[e.From] = append(succ[e.From], e.To)
+	}
+	// dominator tree
+	dominated := make(map[string][]string)
+	for name, idom := range dominators(root, edges) {
+		dominated[idom] = append(dominated[idom], name)
+	}
+	// module of every dep, and number of deps per module
+	modules := make(map[string]string)
+	sizes := make(map[string]int)
+	rootModule := ""
+	for _, n := range nodes {
+		if n.Module == nil {
+			continue
+		}
+		if n.Name == root {
+			rootModule = n.Module.Path
+			continue
+		}
+		modules[n.Name] = n.Module.Path
+		sizes[n.Module.Path]++
+	}
+	var weights []Weight
+	for _, name := range succ[root] {
+		if name == root {
+			continue
+		}
+		exclusive := closure(name, dominated)
+		total := closure(name, succ)
+		delete(total, root)
+		w := Weight{Name: name, Exclusive: len(exclusive), Total: len(total)}
+		// modules whose deps are all exclusive
+		counts := make(map[string]int)
+		for n := range exclusive {
+			if m, ok := modules[n]; ok && m != rootModule {
+				counts[m]++
+			}
+		}
+		for m, count := range counts {
+			if count == sizes[m] {
+				w.ExclusiveModules++
+			}
+		}
+		totalModules := make(map[string]bool)
+		for n := range total {
+			if m, ok := modules[n]; ok && m != rootModule {
+				totalModules[m] = true
+			}
+		}
+		w.TotalModules = len(totalModules)
+		weights = append(weights, w)
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].Exclusive != weights[j].Exclusive {
+			return weights[i].Exclusive > weights[j].Exclusive
+		}
+		if weights[i].Total != weights[j].Total {
+			return weights[i].Total > weights[j].Total
+		}
+		return weights[i].Name < weights[j].Name
+	})
+	return weights, nil
+}
+
+// deps reachable from name through next, name included
+func closure(name string, next map[string][]string) map[string]bool {
+	seen := map[string]bool{name: true}
+	stack := []string{name}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, m := range next[n] {
+			if !seen[m] {
+				seen[m] = true
+				stack = append(stack, m)
+			}
+		}
+	}
+	return seen
+}
@@ -0,0 +1,59 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeights(t *testing.T) {
+	weights, err := gotree.Weights(getCompleteDep())
+	require.NoError(t, err)
+	require.Equal(t, []gotree.Weight{
+		{Name: "b", Exclusive: 4, Total: 4},
+		{Name: "f", Exclusive: 4, Total: 4},
+	}, weights)
+	// d and e are reachable from both b and c
+	weights, err = gotree.Weights(getDiamondDep())
+	require.NoError(t, err)
+	require.Equal(t, []gotree.Weight{
+		{Name: "b", Exclusive: 1, Total: 3},
+		{Name: "c", Exclusive: 1, Total: 3},
+	}, weights)
+	// options are applied to the graph
+	weights, err = gotree.Weights(getCompleteDep(), gotree.WithMaxLevel(2))
+	require.NoError(t, err)
+	require.Equal(t, []gotree.Weight{
+		{Name: "f", Exclusive: 3, Total: 3},
+		{Name: "b", Exclusive: 2, Total: 2},
+	}, weights)
+	// the cycle back to the root is not counted
+	weights, err = gotree.Weights(getCycleDep())
+	require.NoError(t, err)
+	require.Equal(t, []gotree.Weight{{Name: "b", Exclusive: 2, Total: 2}}, weights)
+}
+
+func TestWeights_Modules(t *testing.T) {
+	main := &gotree.Module{Path: "m"}
+	x := &gotree.Module{Path: "x"}
+	y := &gotree.Module{Path: "y"}
+	shared := &mockModuleDep{mockDep{name: "x/b"}, x}
+	root := &mockModuleDep{mockDep{name: "m", deps: []gotree.Dep{
+		&mockModuleDep{mockDep{name: "m/a", deps: []gotree.Dep{
+			shared,
+			&mockModuleDep{mockDep{name: "y/a"}, y},
+		}}, main},
+		&mockModuleDep{mockDep{name: "x/a", deps: []gotree.Dep{shared}}, x},
+	}}, main}
+	weights, err := gotree.Weights(root)
+	require.NoError(t, err)
+	require.Equal(t, []gotree.Weight{
+		// x is still needed by x/a
+		{Name: "m/a", Exclusive: 2, Total: 3, ExclusiveModules: 1, TotalModules: 2},
+		{Name: "x/a", Exclusive: 1, Total: 2, ExclusiveModules: 0, TotalModules: 1},
+	}, weights)
+	weights, err = gotree.Weights(nil)
+	require.NoError(t, err)
+	require.Nil(t, weights)
+}
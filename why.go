@@ -0,0 +1,152 @@
+package gotree
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Get every import chain from d to the dep named target, shortest first.
+// If limit > 0, only the shortest limit chains are returned, the number
+// of chains may grow exponentially with the graph otherwise.
+func Why(d Dep, target string, limit int) [][]string {
+	if d == nil {
+		return nil
+	}
+	g := newGraph(d)
+	if _, ok := g.deps[target]; !ok {
+		return nil
+	}
+	// distance from every dep to target, computed on the reversed graph
+	importedBy := make(map[string][]string)
+	for name, deps := range g.edges {
+		for _, _dep := range deps {
+			importedBy[_dep] = append(importedBy[_dep], name)
+		}
+	}
+	dist := map[string]int{target: 0}
+	queue := []string{target}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, from := range importedBy[name] {
+			if _, ok := dist[from]; !ok {
+				dist[from] = dist[name] + 1
+				queue = append(queue, from)
+			}
+		}
+	}
+	if _, ok := dist[g.root]; !ok {
+		return nil
+	}
+	// best first search, since dist is exact, chains come out shortest first
+	var paths [][]string
+	h := &chainHeap{{path: []string{g.root}, cost: dist[g.root]}}
+	for h.Len() > 0 && (limit <= 0 || len(paths) < limit) {
+		c := heap.Pop(h).(chain)
+		last := c.path[len(c.path)-1]
+		if last == target {
+			paths = append(paths, c.path)
+			continue
+		}
+		for _, next := range g.edges[last] {
+			d, ok := dist[next]
+			if !ok || c.contains(next) {
+				continue
+			}
+			path := make([]string, len(c.path)+1)
+			copy(path, c.path)
+			path[len(c.path)] = next
+			heap.Push(h, chain{path: path, cost: len(c.path) + d})
+		}
+	}
+	return paths
+}
+
+type chain struct {
+	path []string
+	cost int
+}
+
+func (c chain) contains(name string) bool {
+	for _, n := range c.path {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type chainHeap []chain
+
+func (h chainHeap) Len() int { return len(h) }
+
+func (h chainHeap) Less(i, j int) bool {
+	if h[i].cost != h[j].cost {
+		return h[i].cost < h[j].cost
+	}
+	// keep the order stable between runs
+	for k := 0; k < len(h[i].path) && k < len(h[j].path); k++ {
+		if h[i].path[k] != h[j].path[k] {
+			return h[i].path[k] < h[j].path[k]
+		}
+	}
+	return len(h[i].path) < len(h[j].path)
+}
+
+func (h chainHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *chainHeap) Push(x interface{}) { *h = append(*h, x.(chain)) }
+
+func (h *chainHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Merge import chains sharing the same root into a Dep, so they can be
+// printed by Tree or JSONTree. Returns nil if there is no chain.
+func NewPathsDep(paths [][]string) Dep {
+	if len(paths) == 0 {
+		return nil
+	}
+	root := &pathsDep{name: paths[0][0]}
+	for _, path := range paths {
+		cur := root
+		for _, name := range path[1:] {
+			cur = cur.child(name)
+		}
+	}
+	return root
+}
+
+type pathsDep struct {
+	name string
+	deps []*pathsDep
+}
+
+func (d *pathsDep) Name() string {
+	return d.name
+}
+
+func (d *pathsDep) Deps() []Dep {
+	deps := make([]Dep, 0, len(d.deps))
+	for _, _dep := range d.deps {
+		deps = append(deps, _dep)
+	}
+	return deps
+}
+
+func (d *pathsDep) child(name string) *pathsDep {
+	i := sort.Search(len(d.deps), func(i int) bool {
+		return d.deps[i].name >= name
+	})
+	if i < len(d.deps) && d.deps[i].name == name {
+		return d.deps[i]
+	}
+	c := &pathsDep{name: name}
+	d.deps = append(d.deps, nil)
+	copy(d.deps[i+1:], d.deps[i:])
+	d.deps[i] = c
+	return c
+}
@@ -0,0 +1,37 @@
+package gotree_test
+
+import (
+	"testing"
+
+	"github.com/MaruHyl/gotree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhy(t *testing.T) {
+	require.Nil(t, gotree.Why(nil, "e", 0))
+	require.Nil(t, gotree.Why(getDiamondDep(), "x", 0))
+	require.Equal(t, [][]string{
+		{"a", "c", "e"},
+		{"a", "b", "d", "e"},
+		{"a", "c", "d", "e"},
+	}, gotree.Why(getDiamondDep(), "e", 0))
+	require.Equal(t, [][]string{
+		{"a", "c", "e"},
+	}, gotree.Why(getDiamondDep(), "e", 1))
+}
+
+func TestNewPathsDep(t *testing.T) {
+	require.Nil(t, gotree.NewPathsDep(nil))
+	tree, err := gotree.Tree(gotree.NewPathsDep(gotree.Why(getDiamondDep(), "e", 0)))
+	require.NoError(t, err)
+	require.Equal(t, `
+a
+├── b
+│   └── d
+│       └── e
+└── c
+    ├── d
+    │   └── e
+    └── e
+7 deps, 2 direct, 5 indirect (4 unique, 2 direct, 2 indirect)`, "\n"+tree)
+}